@@ -0,0 +1,295 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OverflowPolicy defines what happens to new entries if the queue of the asynchronous output is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the logging goroutine until there is space in the queue.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry which should be added to the full queue.
+	OverflowDropNewest
+	// OverflowDropDebugFirst drops queued debug and trace entries in favor of entries with a higher level.
+	// If there are none, the new entry is dropped.
+	OverflowDropDebugFirst
+)
+
+const (
+	defaultAsyncQueueSize    = 1024
+	defaultAsyncFlushTimeout = 5 * time.Second
+)
+
+// AsyncConfig enables writing the log entries in a background goroutine through a bounded queue.
+type AsyncConfig struct {
+	// QueueSize is the maximum number of queued entries, defaults to 1024.
+	QueueSize int
+	// OverflowPolicy defines how to handle a full queue, defaults to OverflowBlock. Fatal and panic
+	// entries are never dropped and written before logrus exits or panics.
+	OverflowPolicy OverflowPolicy
+	// FlushTimeout limits the time LifecycleStop, LifecycleStopped, Logger.Close and fatal and panic
+	// entries wait for the queue to be written, defaults to 5 seconds.
+	FlushTimeout time.Duration
+}
+
+func (c *AsyncConfig) queueSize() int {
+	if c.QueueSize > 0 {
+		return c.QueueSize
+	}
+	return defaultAsyncQueueSize
+}
+
+func (c *AsyncConfig) flushTimeout() time.Duration {
+	if c.FlushTimeout > 0 {
+		return c.FlushTimeout
+	}
+	return defaultAsyncFlushTimeout
+}
+
+type asyncRecord struct {
+	level logrus.Level
+	data  []byte
+	// out overrides the output of the asyncWriter, e.g. for sinks.
+	out     io.Writer
+	flushed chan struct{}
+	// stop ends the background goroutine after flushed is closed.
+	stop bool
+}
+
+type asyncWriter struct {
+	out    io.Writer
+	config AsyncConfig
+
+	mu       sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	queue    []asyncRecord
+	closed   bool
+	// stopped is closed when the background goroutine stops after Close
+	stopped chan struct{}
+
+	dropped atomic.Uint64
+}
+
+func newAsyncWriter(out io.Writer, config AsyncConfig) *asyncWriter {
+	w := &asyncWriter{out: out, config: config, stopped: make(chan struct{})}
+	w.notEmpty = sync.NewCond(&w.mu)
+	w.notFull = sync.NewCond(&w.mu)
+
+	go w.run()
+	return w
+}
+
+// Write queues p. It is used if something writes to the output of the Logger directly.
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		w.enqueue(logrus.InfoLevel, p)
+	}
+	return len(p), nil
+}
+
+func (w *asyncWriter) enqueue(level logrus.Level, p []byte) {
+	w.enqueueTo(nil, level, p)
+}
+
+// enqueueTo queues p to be written to out instead of the output of the asyncWriter, if out is not nil.
+// Fatal and panic entries are never dropped and written before enqueueTo returns, because logrus
+// exits or panics right after them.
+func (w *asyncWriter) enqueueTo(out io.Writer, level logrus.Level, p []byte) {
+	// p may be the buffer of the entry, which is reused by logrus after the write
+	record := asyncRecord{level: level, data: append([]byte(nil), p...), out: out}
+	final := level <= logrus.FatalLevel
+
+	policy := w.config.OverflowPolicy
+	if final {
+		policy = OverflowBlock
+	}
+
+	w.mu.Lock()
+	for !w.closed && len(w.queue) >= w.config.queueSize() {
+		switch policy {
+		case OverflowDropNewest:
+			w.dropped.Add(1)
+			w.mu.Unlock()
+			return
+		case OverflowDropDebugFirst:
+			if isDebugLevel(level) || !w.dropQueuedDebug() {
+				w.dropped.Add(1)
+				w.mu.Unlock()
+				return
+			}
+		default:
+			w.notFull.Wait()
+		}
+	}
+
+	if !w.closed {
+		w.queue = append(w.queue, record)
+		w.notEmpty.Signal()
+		w.mu.Unlock()
+
+		if final {
+			ctx, cancel := context.WithTimeout(context.Background(), w.config.flushTimeout())
+			defer cancel()
+			_ = w.Flush(ctx)
+		}
+		return
+	}
+	w.mu.Unlock()
+
+	// the entry is written synchronously after the queued entries
+	<-w.stopped
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.write(record)
+}
+
+// dropQueuedDebug removes the oldest queued debug entry, it must be called with the lock held.
+func (w *asyncWriter) dropQueuedDebug() bool {
+	for i, record := range w.queue {
+		if record.flushed == nil && isDebugLevel(record.level) {
+			w.queue = append(w.queue[:i], w.queue[i+1:]...)
+			w.dropped.Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// Flush waits until all entries queued before the call are written or ctx is done.
+func (w *asyncWriter) Flush(ctx context.Context) error {
+	return w.flush(ctx, false)
+}
+
+// Close writes the queued entries and stops the background goroutine, it waits until the entries are
+// written or ctx is done. Entries logged afterwards are written synchronously.
+func (w *asyncWriter) Close(ctx context.Context) error {
+	return w.flush(ctx, true)
+}
+
+func (w *asyncWriter) flush(ctx context.Context, stop bool) error {
+	flushed := make(chan struct{})
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return nil
+	}
+	w.closed = stop
+	w.queue = append(w.queue, asyncRecord{flushed: flushed, stop: stop})
+	w.notEmpty.Signal()
+	w.mu.Unlock()
+
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *asyncWriter) run() {
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 {
+			w.notEmpty.Wait()
+		}
+		record := w.queue[0]
+		w.queue = w.queue[1:]
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		if record.flushed != nil {
+			close(record.flushed)
+			if record.stop {
+				close(w.stopped)
+				return
+			}
+			continue
+		}
+
+		w.write(record)
+	}
+}
+
+func (w *asyncWriter) write(record asyncRecord) {
+	out := w.out
+	if record.out != nil {
+		out = record.out
+	}
+	if _, err := out.Write(record.data); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write to log, %v\n", err)
+	}
+}
+
+func isDebugLevel(level logrus.Level) bool {
+	return level >= logrus.DebugLevel
+}
+
+// asyncFormatter hands the formatted entries over to the asyncWriter instead of returning them to logrus.
+type asyncFormatter struct {
+	logrus.Formatter
+	writer *asyncWriter
+}
+
+func (f *asyncFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	serialized, err := f.Formatter.Format(entry)
+	if err != nil || len(serialized) == 0 {
+		return nil, err
+	}
+
+	f.writer.enqueue(entry.Level, serialized)
+	return nil, nil
+}
+
+// Flush waits until the entries of the global Log are written, see Logger.Flush.
+func Flush(ctx context.Context) error {
+	return Log.Flush(ctx)
+}
+
+// Flush waits until all entries logged before are written, if LogConfig.Async is enabled, and exports
+// the pending records of LogConfig.OTelLogs. It returns the error of ctx, if it is done before.
+func (l *Logger) Flush(ctx context.Context) error {
+	if l.config.OTelLogs != nil {
+		if err := l.config.OTelLogs.ForceFlush(ctx); err != nil {
+			return err
+		}
+	}
+	if l.async == nil {
+		return nil
+	}
+	return l.async.Flush(ctx)
+}
+
+// DroppedEntries returns the number of entries dropped because the queue of LogConfig.Async was full.
+func (l *Logger) DroppedEntries() uint64 {
+	if l.async == nil {
+		return 0
+	}
+	return l.async.dropped.Load()
+}
+
+// flushOnStop flushes the Logger before the application stops, bounded by AsyncConfig.FlushTimeout.
+func (l *Logger) flushOnStop() {
+	if l.async == nil && l.config.OTelLogs == nil {
+		return
+	}
+
+	timeout := defaultAsyncFlushTimeout
+	if l.async != nil {
+		timeout = l.async.config.flushTimeout()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_ = l.Flush(ctx)
+}
@@ -0,0 +1,187 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingWriter blocks all writes until it is released.
+type blockingWriter struct {
+	release chan struct{}
+	mu      sync.Mutex
+	buffer  bytes.Buffer
+}
+
+func newBlockingWriter() *blockingWriter {
+	return &blockingWriter{release: make(chan struct{})}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.Write(p)
+}
+
+func (w *blockingWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buffer.String()
+}
+
+func Test_Async_FlushWritesAllEntries(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("info", &LogConfig{Output: w, EnableTextLogging: true, Async: &AsyncConfig{}})
+	require.NoError(t, err)
+
+	logger.Info("first")
+	logger.Info("second")
+	close(w.release)
+
+	require.NoError(t, logger.Flush(context.Background()))
+	assert.Contains(t, w.String(), "msg=first")
+	assert.Contains(t, w.String(), "msg=second")
+	assert.Zero(t, logger.DroppedEntries())
+}
+
+func Test_Async_CloseWritesPendingEntries(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("info", &LogConfig{Output: w, EnableTextLogging: true, Async: &AsyncConfig{}})
+	require.NoError(t, err)
+
+	logger.Info("first")
+	logger.Info("second")
+	assert.Empty(t, w.String())
+	close(w.release)
+
+	require.NoError(t, logger.Close())
+	assert.Contains(t, w.String(), "msg=first")
+	assert.Contains(t, w.String(), "msg=second")
+
+	// the background goroutine is stopped, later entries are written synchronously
+	select {
+	case <-logger.async.stopped:
+	default:
+		t.Fatal("the background goroutine is not stopped")
+	}
+	logger.Info("after close")
+	assert.Contains(t, w.String(), "msg=\"after close\"")
+	assert.NoError(t, logger.Close())
+}
+
+func Test_Async_FatalAndPanicEntriesAreWrittenBeforeExit(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("info", &LogConfig{Output: w, EnableTextLogging: true, Async: &AsyncConfig{}})
+	require.NoError(t, err)
+
+	exitCode := -1
+	logger.ExitFunc = func(code int) {
+		exitCode = code
+	}
+	time.AfterFunc(50*time.Millisecond, func() { close(w.release) })
+
+	logger.Info("before")
+	logger.Fatal("fatal")
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, w.String(), "msg=before")
+	assert.Contains(t, w.String(), "msg=fatal")
+
+	assert.Panics(t, func() { logger.Panic("panic") })
+	assert.Contains(t, w.String(), "msg=panic")
+}
+
+func Test_Async_FlushRespectsContext(t *testing.T) {
+	w := newBlockingWriter()
+	defer close(w.release)
+	logger, err := NewLogger("info", &LogConfig{Output: w, Async: &AsyncConfig{}})
+	require.NoError(t, err)
+
+	logger.Info("blocked")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(logger.Flush(ctx), context.DeadlineExceeded))
+}
+
+func Test_Async_DropNewest(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("info", &LogConfig{
+		Output:            w,
+		EnableTextLogging: true,
+		Async:             &AsyncConfig{QueueSize: 2, OverflowPolicy: OverflowDropNewest},
+	})
+	require.NoError(t, err)
+
+	// the first entry is taken by the writer goroutine and blocks it
+	logger.Info("entry-0")
+	assert.Eventually(t, func() bool { return queueLen(logger.async) == 0 }, time.Second, time.Millisecond)
+	for i := 1; i <= 4; i++ {
+		logger.Infof("entry-%d", i)
+	}
+	close(w.release)
+	require.NoError(t, logger.Flush(context.Background()))
+
+	assert.Equal(t, uint64(2), logger.DroppedEntries())
+	assert.Contains(t, w.String(), "entry-2")
+	assert.NotContains(t, w.String(), "entry-3")
+}
+
+func Test_Async_DropDebugFirst(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("debug", &LogConfig{
+		Output:            w,
+		EnableTextLogging: true,
+		Async:             &AsyncConfig{QueueSize: 2, OverflowPolicy: OverflowDropDebugFirst},
+	})
+	require.NoError(t, err)
+
+	logger.Info("entry-0")
+	assert.Eventually(t, func() bool { return queueLen(logger.async) == 0 }, time.Second, time.Millisecond)
+	logger.Debug("debug-1")
+	logger.Info("info-2")
+	logger.Error("error-3")
+	logger.Debug("debug-4")
+	close(w.release)
+	require.NoError(t, logger.Flush(context.Background()))
+
+	assert.Equal(t, uint64(2), logger.DroppedEntries())
+	assert.NotContains(t, w.String(), "debug-1")
+	assert.Contains(t, w.String(), "info-2")
+	assert.Contains(t, w.String(), "error-3")
+	assert.NotContains(t, w.String(), "debug-4")
+}
+
+func Test_Async_LifecycleStoppedFlushes(t *testing.T) {
+	w := newBlockingWriter()
+	logger, err := NewLogger("info", &LogConfig{Output: w, Async: &AsyncConfig{}})
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(w.release)
+	}()
+	logger.LifecycleStopped("my-app", nil)
+
+	assert.Contains(t, w.String(), "application stopped: my-app")
+}
+
+func Test_Flush_WithoutAsync(t *testing.T) {
+	logger, err := NewLogger("info", &LogConfig{Output: bytes.NewBuffer(nil)})
+	require.NoError(t, err)
+
+	assert.NoError(t, logger.Flush(context.Background()))
+	assert.Zero(t, logger.DroppedEntries())
+}
+
+func queueLen(w *asyncWriter) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.queue)
+}
@@ -0,0 +1,253 @@
+package logging
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBodyCaptureSize = 4096
+
+// DefaultBodyContentTypes are the content types captured if BodyCaptureConfig.ContentTypes is not set.
+var DefaultBodyContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"text/*",
+}
+
+// BodyRedactor returns the body with sensitive data masked, contentType is the media type of the body.
+// The body may be truncated, so it is not necessarily valid json for example.
+type BodyRedactor func(contentType, body string) string
+
+// BodyCaptureConfig captures the request and response bodies of failed requests. The bodies are
+// written to the access log in the fields PayloadField and ResponseField. The Redaction of the
+// LogConfig is applied to them like to all fields. Form bodies are not captured by default, because
+// they often contain credentials, e.g. of login forms. Use RedactFormFields if they are captured.
+type BodyCaptureConfig struct {
+	// MaxSize is the number of bytes captured of each body, defaults to 4096. Larger bodies are
+	// truncated and marked with TruncatedMarker.
+	MaxSize int
+	// MinStatus and MaxStatus are the range of status codes the bodies are written for,
+	// they default to 400 and 599.
+	MinStatus int
+	MaxStatus int
+	// ContentTypes are the media types of the captured bodies, e.g. "application/json" or "text/*"
+	// for all text types. Defaults to DefaultBodyContentTypes.
+	ContentTypes []string
+	// Redactors mask sensitive data in the bodies before they are logged, see RedactJSONFields and
+	// RedactFormFields.
+	Redactors []BodyRedactor
+}
+
+func (c *BodyCaptureConfig) maxSize() int {
+	if c.MaxSize > 0 {
+		return c.MaxSize
+	}
+	return defaultBodyCaptureSize
+}
+
+func (c *BodyCaptureConfig) matchesStatus(statusCode int) bool {
+	minStatus, maxStatus := c.MinStatus, c.MaxStatus
+	if minStatus == 0 {
+		minStatus = 400
+	}
+	if maxStatus == 0 {
+		maxStatus = 599
+	}
+	return statusCode >= minStatus && statusCode <= maxStatus
+}
+
+// mediaType returns the media type of the content type if it is captured.
+func (c *BodyCaptureConfig) mediaType(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+
+	contentTypes := c.ContentTypes
+	if contentTypes == nil {
+		contentTypes = DefaultBodyContentTypes
+	}
+	for _, captured := range contentTypes {
+		if prefix, ok := strings.CutSuffix(captured, "*"); ok {
+			if strings.HasPrefix(mediaType, strings.ToLower(prefix)) {
+				return mediaType, true
+			}
+		} else if strings.EqualFold(captured, mediaType) {
+			return mediaType, true
+		}
+	}
+	return "", false
+}
+
+// field returns the captured body for the access log, if its content type is captured.
+func (c *BodyCaptureConfig) field(contentType string, body *bodyBuffer) (string, bool) {
+	if body == nil || body.size == 0 {
+		return "", false
+	}
+	mediaType, ok := c.mediaType(contentType)
+	if !ok {
+		return "", false
+	}
+
+	value := body.String()
+	for _, redact := range c.Redactors {
+		value = redact(mediaType, value)
+	}
+	if body.truncated() {
+		value = withTruncatedMarker(value, body.size)
+	}
+	return value, true
+}
+
+// bodyCapture records the request and response bodies of a request.
+type bodyCapture struct {
+	config   *BodyCaptureConfig
+	request  *bodyBuffer
+	response *bodyBuffer
+}
+
+func newBodyCapture(config *BodyCaptureConfig) *bodyCapture {
+	if config == nil {
+		return nil
+	}
+	return &bodyCapture{
+		config:   config,
+		request:  newBodyBuffer(config.maxSize()),
+		response: newBodyBuffer(config.maxSize()),
+	}
+}
+
+// capturesResponse reports whether the response body with the status code is captured.
+func (c *bodyCapture) capturesResponse(statusCode int) bool {
+	return c != nil && c.config.matchesStatus(statusCode)
+}
+
+// wrapRequest returns a copy of r capturing the request body, while it is read by the handler.
+func (c *bodyCapture) wrapRequest(r *http.Request) *http.Request {
+	if r.Body == nil || r.Body == http.NoBody {
+		return r
+	}
+	r = r.WithContext(r.Context())
+	r.Body = &capturingReadCloser{ReadCloser: r.Body, buf: c.request}
+	return r
+}
+
+// fields returns the captured bodies, if the status code and the content types are captured.
+// The part of the request body not read by the handler is read up to the size limit.
+func (c *bodyCapture) fields(r *http.Request, w http.ResponseWriter, statusCode int) logrus.Fields {
+	fields := logrus.Fields{}
+	if c == nil || !c.config.matchesStatus(statusCode) {
+		return fields
+	}
+
+	if body, ok := r.Body.(*capturingReadCloser); ok && !c.request.full() {
+		_, _ = io.Copy(io.Discard, io.LimitReader(body, int64(c.config.maxSize()+utf8.UTFMax)))
+	}
+	// the rest of the body is not read, but its size may be known
+	if r.ContentLength > int64(c.request.size) {
+		c.request.size = int(r.ContentLength)
+	}
+
+	if payload, ok := c.config.field(r.Header.Get("Content-Type"), c.request); ok {
+		fields[PayloadField] = payload
+	}
+	if response, ok := c.config.field(w.Header().Get("Content-Type"), c.response); ok {
+		fields[ResponseField] = response
+	}
+	return fields
+}
+
+// bodyBuffer keeps the first bytes of a body and counts its size. It keeps a few bytes more than
+// the limit to cut the body without splitting runes. The size may exceed the buffered bytes, even
+// if they are below the limit, when the body is not read completely.
+type bodyBuffer struct {
+	buf   []byte
+	limit int
+	size  int
+}
+
+func newBodyBuffer(limit int) *bodyBuffer {
+	return &bodyBuffer{limit: limit}
+}
+
+func (b *bodyBuffer) Write(p []byte) (int, error) {
+	if keep := b.limit + utf8.UTFMax - len(b.buf); keep > 0 {
+		b.buf = append(b.buf, p[:min(keep, len(p))]...)
+	}
+	b.size += len(p)
+	return len(p), nil
+}
+
+func (b *bodyBuffer) full() bool {
+	return len(b.buf) >= b.limit+utf8.UTFMax
+}
+
+// truncated reports whether the body is larger than the part returned by String.
+func (b *bodyBuffer) truncated() bool {
+	return b.size > min(b.limit, len(b.buf))
+}
+
+// String returns the captured body, cut to the limit without splitting runes.
+func (b *bodyBuffer) String() string {
+	if len(b.buf) <= b.limit {
+		return string(b.buf)
+	}
+	cut := b.limit
+	for cut > 0 && !utf8.RuneStart(b.buf[cut]) {
+		cut--
+	}
+	return string(b.buf[:cut])
+}
+
+type capturingReadCloser struct {
+	io.ReadCloser
+	buf *bodyBuffer
+}
+
+func (r *capturingReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	_, _ = r.buf.Write(p[:n])
+	return n, err
+}
+
+// RedactJSONFields returns a BodyRedactor masking the values of the json fields with the names,
+// case-insensitive and on all levels. Bodies of other content types than json are not changed.
+func RedactJSONFields(names ...string) BodyRedactor {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	pattern := regexp.MustCompile(`("(?i:` + strings.Join(quoted, "|") + `)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^\s,}\]]+)`)
+
+	return func(contentType, body string) string {
+		if contentType != "application/json" && !strings.HasSuffix(contentType, "+json") {
+			return body
+		}
+		return pattern.ReplaceAllString(body, `${1}"`+defaultRedactionMask+`"`)
+	}
+}
+
+// RedactFormFields returns a BodyRedactor masking the values of the form fields with the names,
+// case-insensitive. Bodies of other content types than application/x-www-form-urlencoded are not changed.
+func RedactFormFields(names ...string) BodyRedactor {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(url.QueryEscape(name))
+	}
+	pattern := regexp.MustCompile(`((?:^|&)(?i:` + strings.Join(quoted, "|") + `)=)[^&]*`)
+
+	return func(contentType, body string) string {
+		if contentType != "application/x-www-form-urlencoded" {
+			return body
+		}
+		return pattern.ReplaceAllString(body, `${1}`+defaultRedactionMask)
+	}
+}
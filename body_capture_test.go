@@ -0,0 +1,225 @@
+package logging
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveWithBodyCapture(t *testing.T, config *BodyCaptureConfig, handler http.HandlerFunc, r *http.Request) map[string]any {
+	t.Helper()
+
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(handler, LogMiddlewareConfig{Logger: logger, CaptureBodies: config})
+	require.NoError(t, err)
+
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+	return mapFromBuffer(b)
+}
+
+func Test_BodyCapture_FailedRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", strings.NewReader(`{"amount":-1}`))
+	r.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"amount":-1}`, string(body))
+
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"title":"invalid amount"}`))
+	}, r)
+
+	assert.Equal(t, `{"amount":-1}`, data[PayloadField])
+	assert.Equal(t, `{"title":"invalid amount"}`, data[ResponseField])
+}
+
+func Test_BodyCapture_ReadsUnreadRequestBody(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", strings.NewReader("unread body"))
+	r.Header.Set("Content-Type", "text/plain")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, r)
+
+	assert.Equal(t, "unread body", data[PayloadField])
+	assert.NotContains(t, data, ResponseField)
+}
+
+func Test_BodyCapture_SuccessfulRequestsAreNotCaptured(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", strings.NewReader("request"))
+	r.Header.Set("Content-Type", "text/plain")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("response"))
+	}, r)
+
+	assert.NotContains(t, data, PayloadField)
+	assert.NotContains(t, data, ResponseField)
+}
+
+func Test_BodyCapture_StatusRange(t *testing.T) {
+	config := &BodyCaptureConfig{MinStatus: 500}
+	handler := func(statusCode int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.WriteHeader(statusCode)
+			_, _ = w.Write([]byte("response"))
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	assert.NotContains(t, serveWithBodyCapture(t, config, handler(http.StatusNotFound), r), ResponseField)
+	assert.Equal(t, "response", serveWithBodyCapture(t, config, handler(http.StatusBadGateway), r)[ResponseField])
+}
+
+func Test_BodyCapture_ContentTypes(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", bytes.NewReader([]byte{0x89, 'P', 'N', 'G'}))
+	r.Header.Set("Content-Type", "image/png")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{ContentTypes: []string{"application/*"}}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"errors":[]}`))
+	}, r)
+
+	assert.NotContains(t, data, PayloadField)
+	assert.Equal(t, `{"errors":[]}`, data[ResponseField])
+}
+
+func Test_BodyCapture_Truncates(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", strings.NewReader("äöü"+strings.Repeat("x", 100)))
+	r.Header.Set("Content-Type", "text/plain")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{MaxSize: 5}, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = io.Copy(w, strings.NewReader(strings.Repeat("y", 10)))
+	}, r)
+
+	assert.Equal(t, "äö..."+TruncatedMarker+"(106 bytes)", data[PayloadField])
+	assert.Equal(t, "yyyyy..."+TruncatedMarker+"(10 bytes)", data[ResponseField])
+}
+
+type closedBody struct{}
+
+func (closedBody) Read([]byte) (int, error) {
+	return 0, http.ErrBodyReadAfterClose
+}
+
+func (closedBody) Close() error {
+	return nil
+}
+
+func Test_BodyCapture_UnreadBodyWithLargeContentLength(t *testing.T) {
+	for name, body := range map[string]io.ReadCloser{
+		"closed": closedBody{},
+		"short":  io.NopCloser(strings.NewReader("abc")),
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", nil)
+			r.Header.Set("Content-Type", "text/plain")
+			r.Body = body
+			r.ContentLength = 10000
+
+			var data map[string]any
+			require.NotPanics(t, func() {
+				data = serveWithBodyCapture(t, &BodyCaptureConfig{}, func(w http.ResponseWriter, r *http.Request) {
+					_ = r.Body.Close()
+					w.WriteHeader(http.StatusBadRequest)
+				}, r)
+			})
+
+			assert.Equal(t, 400.0, data["response_status"])
+			assert.Contains(t, data[PayloadField], TruncatedMarker+"(10000 bytes)")
+		})
+	}
+}
+
+func Test_BodyCapture_Redactors(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo",
+		strings.NewReader(`{"user":"jane","Password":"se\"cret","card":{"pin":1234}}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	upper := func(contentType, body string) string {
+		return strings.ToUpper(body)
+	}
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{Redactors: []BodyRedactor{RedactJSONFields("password", "pin"), upper}},
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}, r)
+
+	assert.Equal(t, `{"USER":"JANE","PASSWORD":"[REDACTED]","CARD":{"PIN":"[REDACTED]"}}`, data[PayloadField])
+}
+
+func Test_RedactJSONFields_TruncatedAndOtherContentTypes(t *testing.T) {
+	redact := RedactJSONFields("token")
+
+	assert.Equal(t, `{"token":"[REDACTED]"`, redact("application/json", `{"token":"abc`))
+	assert.Equal(t, `token=abc`, redact("application/x-www-form-urlencoded", `token=abc`))
+}
+
+func Test_BodyCapture_FormBodiesAreNotCapturedByDefault(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/login", strings.NewReader("user=jane&password=hunter2"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, r)
+
+	assert.NotContains(t, data, PayloadField)
+}
+
+func Test_RedactFormFields(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/login", strings.NewReader("user=jane&Password=hunter2&old_password=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	data := serveWithBodyCapture(t, &BodyCaptureConfig{
+		ContentTypes: []string{"application/x-www-form-urlencoded"},
+		Redactors:    []BodyRedactor{RedactFormFields("password")},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, r)
+
+	assert.Equal(t, "user=jane&Password=[REDACTED]&old_password=x", data[PayloadField])
+	assert.Equal(t, `{"password":"x"}`, RedactFormFields("password")("application/json", `{"password":"x"}`))
+}
+
+func Test_BodyCapture_SuccessfulResponsesAreNotBuffered(t *testing.T) {
+	for _, test := range []struct {
+		statusCode int
+		captured   int
+	}{
+		{http.StatusOK, 0},
+		{http.StatusBadGateway, 8},
+	} {
+		capture := newBodyCapture(&BodyCaptureConfig{})
+		metrics := &responseMetrics{capture: capture}
+		w := metrics.wrap(readerFromRecorder{httptest.NewRecorder()})
+
+		w.WriteHeader(test.statusCode)
+		_, _ = w.Write([]byte("resp"))
+		_, _ = w.(io.ReaderFrom).ReadFrom(strings.NewReader("onse"))
+
+		assert.Equal(t, test.captured, capture.response.size)
+	}
+}
+
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	return io.Copy(r.ResponseRecorder, src)
+}
@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// DefaultClientIPHeaders are the headers checked if ClientIPConfig.Headers is not set.
+var DefaultClientIPHeaders = []string{"Forwarded", "X-Forwarded-For"}
+
+// ClientIPConfig resolves the client ip of the access log behind proxies. Headers are only used if
+// the request comes from a trusted proxy, because any client can set them.
+type ClientIPConfig struct {
+	// TrustedProxies are the networks of the proxies in CIDR notation, e.g. "10.0.0.0/8", or single ips.
+	TrustedProxies []string
+	// Headers are checked in order for the client ip, the first valid header wins. The headers may
+	// contain lists like X-Forwarded-For or Forwarded of RFC 7239, the right-most ip which is not
+	// a trusted proxy is the client ip. Defaults to DefaultClientIPHeaders.
+	Headers []string
+	// Anonymize zeroes the last octet of IPv4 addresses and the last 64 bits of IPv6 addresses.
+	Anonymize bool
+}
+
+type clientIPResolver struct {
+	trusted   []netip.Prefix
+	headers   []string
+	anonymize bool
+}
+
+func newClientIPResolver(config *ClientIPConfig) (*clientIPResolver, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	resolver := &clientIPResolver{headers: config.Headers, anonymize: config.Anonymize}
+	if resolver.headers == nil {
+		resolver.headers = DefaultClientIPHeaders
+	}
+
+	for _, proxy := range config.TrustedProxies {
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(proxy)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid trusted proxy: '%s': %w", proxy, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		resolver.trusted = append(resolver.trusted, prefix.Masked())
+	}
+	return resolver, nil
+}
+
+// resolve returns the client ip of the request.
+func (c *clientIPResolver) resolve(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	peer, ok := parseIP(host)
+	if !ok {
+		// the host can not be anonymized, if it is not an ip
+		if c.anonymize {
+			return ""
+		}
+		return host
+	}
+	if !c.isTrusted(peer) {
+		return c.format(peer)
+	}
+
+	for _, header := range c.headers {
+		if ip, ok := c.fromHeader(r.Header, header); ok {
+			return c.format(ip)
+		}
+	}
+	return c.format(peer)
+}
+
+// fromHeader returns the right-most ip of the header, which is not a trusted proxy. If all of them
+// are trusted, the left-most ip is returned.
+func (c *clientIPResolver) fromHeader(header http.Header, name string) (netip.Addr, bool) {
+	var values []string
+	for _, line := range header.Values(name) {
+		if strings.EqualFold(name, "Forwarded") {
+			values = append(values, forwardedFor(line)...)
+		} else {
+			values = append(values, strings.Split(line, ",")...)
+		}
+	}
+
+	var ip netip.Addr
+	for i := len(values) - 1; i >= 0; i-- {
+		var ok bool
+		if ip, ok = parseIP(values[i]); !ok {
+			// anything left of an invalid entry can not be trusted
+			return netip.Addr{}, false
+		}
+		if !c.isTrusted(ip) {
+			return ip, true
+		}
+	}
+	return ip, ip.IsValid()
+}
+
+func (c *clientIPResolver) isTrusted(ip netip.Addr) bool {
+	for _, prefix := range c.trusted {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *clientIPResolver) format(ip netip.Addr) string {
+	if !c.anonymize {
+		return ip.String()
+	}
+
+	bits := 64
+	if ip.Is4() {
+		bits = 24
+	}
+	prefix, _ := ip.WithZone("").Prefix(bits)
+	return prefix.Addr().String()
+}
+
+// forwardedFor returns the values of the for parameters of a Forwarded header of RFC 7239,
+// e.g. `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`.
+func forwardedFor(header string) []string {
+	var values []string
+	for _, element := range strings.Split(header, ",") {
+		value := ""
+		for _, pair := range strings.Split(element, ";") {
+			key, v, _ := strings.Cut(strings.TrimSpace(pair), "=")
+			if strings.EqualFold(key, "for") {
+				value = v
+			}
+		}
+		// elements without a for parameter are kept, so they invalidate the list
+		values = append(values, value)
+	}
+	return values
+}
+
+// parseIP parses an ip with an optional port, brackets and quotes, e.g. "[2001:db8::1]:4711".
+func parseIP(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if addrPort, err := netip.ParseAddrPort(value); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClientIPResolver(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     ClientIPConfig
+		remoteAddr string
+		headers    map[string][]string
+		expected   string
+	}{
+		{
+			name:       "untrusted peer ignores headers",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "203.0.113.7:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "right-most untrusted of X-Forwarded-For",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1"}},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.1", "203.0.113.7, 192.0.2.1"}},
+			expected:   "203.0.113.7",
+		},
+		{
+			name:       "left-most if all are trusted",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.1.1.1, 10.2.2.2"}},
+			expected:   "10.1.1.1",
+		},
+		{
+			name:       "invalid entry falls back to the next header",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}, Headers: []string{"X-Forwarded-For", "X-Real-Ip"}},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"garbage, 10.1.1.1"}, "X-Real-Ip": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "peer if no header is set",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.2:1234",
+			expected:   "10.0.0.2",
+		},
+		{
+			name:       "Forwarded of RFC 7239",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8", "2001:db8:cafe::/48"}},
+			remoteAddr: "[2001:db8:cafe::17]:4711",
+			headers:    map[string][]string{"Forwarded": {`for="[2001:db8::1]:4711";proto=https, For=10.0.0.3;by=10.0.0.4`}},
+			expected:   "2001:db8::1",
+		},
+		{
+			name:       "Forwarded with obfuscated identifier",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "10.0.0.2:1234",
+			headers:    map[string][]string{"Forwarded": {"for=_hidden"}, "X-Forwarded-For": {"198.51.100.1"}},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "anonymized IPv4",
+			config:     ClientIPConfig{Anonymize: true},
+			remoteAddr: "203.0.113.7:1234",
+			expected:   "203.0.113.0",
+		},
+		{
+			name:       "anonymized IPv6",
+			config:     ClientIPConfig{Anonymize: true},
+			remoteAddr: "[2001:db8:1:2:3:4:5:6]:1234",
+			expected:   "2001:db8:1:2::",
+		},
+		{
+			name:       "unparseable peer",
+			config:     ClientIPConfig{},
+			remoteAddr: "@unix",
+			expected:   "@unix",
+		},
+		{
+			name:       "unparseable peer is not logged if anonymized",
+			config:     ClientIPConfig{Anonymize: true},
+			remoteAddr: "@unix",
+			expected:   "",
+		},
+		{
+			name:       "IPv4-mapped IPv6",
+			config:     ClientIPConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+			remoteAddr: "[::ffff:10.0.0.2]:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1:5678"}},
+			expected:   "198.51.100.1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resolver, err := newClientIPResolver(&test.config)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+			r.RemoteAddr = test.remoteAddr
+			for name, values := range test.headers {
+				r.Header[http.CanonicalHeaderKey(name)] = values
+			}
+
+			assert.Equal(t, test.expected, resolver.resolve(r))
+		})
+	}
+}
+
+func Test_ClientIPResolver_InvalidTrustedProxy(t *testing.T) {
+	_, err := AddLogMiddleware(http.NotFoundHandler(), LogMiddlewareConfig{
+		ClientIP: &ClientIPConfig{TrustedProxies: []string{"10.0.0.0/33"}},
+	})
+	assert.ErrorContains(t, err, "invalid trusted proxy")
+}
+
+func Test_LogMiddleware_ClientIP(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), LogMiddlewareConfig{Logger: logger, ClientIP: &ClientIPConfig{TrustedProxies: []string{"192.0.2.0/24"}}})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	r.Header.Set("X-Real-Ip", "1.1.1.1")
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "198.51.100.1", logRecordFromBuffer(b).RemoteIP)
+}
@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/snabble/go-logging/v2/tracex"
+	"golang.org/x/term"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[36m"
+	colorGray   = "\x1b[90m"
+	colorBold   = "\x1b[1m"
+)
+
+// stackFieldsForConsole are expanded as indented lines below the entry.
+var stackFieldsForConsole = []string{"stacktrace", "stack"}
+
+// httpFieldsForConsole are part of the compact layout of access and call entries.
+var httpFieldsForConsole = []string{TypeField, "@timestamp", "method", "url", "full_url", "response_status", DurationField}
+
+// stacktraceFramePattern matches a Frame formatted with %+v as done by Entry.WithError.
+var stacktraceFramePattern = regexp.MustCompile(`\{Function:(\S*) Symbol:\S* Module:(\S*) Package:\S* Filename:(\S*) AbsPath:(\S*) Lineno:(\d+)`)
+
+// ConsoleFormatter generates colored and aligned output for local development. Access and call entries
+// are shown as "200 GET /path 12ms", stacktraces are expanded to one indented line per frame.
+type ConsoleFormatter struct {
+	// DisableColors disables the ANSI colors.
+	DisableColors bool
+	// TimestampFormat sets the format used for timestamps, defaults to "15:04:05.000".
+	TimestampFormat string
+}
+
+func (f *ConsoleFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	fields := maps.Clone(entry.Data)
+	if fields == nil {
+		fields = logrus.Fields{}
+	}
+	tracex.TraceAndSpan(entry.Context, fields)
+
+	timeStampFormat := f.TimestampFormat
+	if timeStampFormat == "" {
+		timeStampFormat = "15:04:05.000"
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+
+	f.colored(b, colorGray, entry.Time.Format(timeStampFormat))
+	b.WriteByte(' ')
+	f.colored(b, levelColor(entry.Level), fmt.Sprintf("%-5s", consoleLevel(entry.Level)))
+	b.WriteByte(' ')
+
+	message := entry.Message
+	if t := fields[TypeField]; t == TypeAccess || t == TypeCall {
+		message = compactHTTPMessage(fields)
+		for _, k := range httpFieldsForConsole {
+			delete(fields, k)
+		}
+	}
+	f.colored(b, colorBold, fmt.Sprintf("%-44s", strings.ReplaceAll(message, "\n", " ")))
+
+	stacks := map[string]any{}
+	for _, k := range stackFieldsForConsole {
+		if v, ok := fields[k]; ok {
+			stacks[k] = v
+			delete(fields, k)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		pair := &bytes.Buffer{}
+		writeLogfmtValue(pair, k, fields[k])
+		b.WriteByte(' ')
+		b.Write(pair.Bytes())
+	}
+	b.WriteByte('\n')
+
+	for _, k := range stackFieldsForConsole {
+		if v, ok := stacks[k]; ok {
+			f.colored(b, colorGray, "    "+k+":")
+			b.WriteByte('\n')
+			writeStackLines(b, fmt.Sprint(v))
+		}
+	}
+
+	return b.Bytes(), nil
+}
+
+func (f *ConsoleFormatter) colored(b *bytes.Buffer, color, s string) {
+	if f.DisableColors {
+		b.WriteString(s)
+		return
+	}
+	b.WriteString(color)
+	b.WriteString(s)
+	b.WriteString(colorReset)
+}
+
+// compactHTTPMessage returns "200 GET /path 12ms" for access and call entries.
+func compactHTTPMessage(fields logrus.Fields) string {
+	parts := []string{}
+	if status, ok := fields["response_status"]; ok {
+		parts = append(parts, fmt.Sprint(status))
+	} else {
+		parts = append(parts, "---")
+	}
+	if method, ok := fields["method"]; ok {
+		parts = append(parts, fmt.Sprint(method))
+	}
+	if url, ok := fields["full_url"]; ok {
+		parts = append(parts, fmt.Sprint(url))
+	} else if url, ok := fields["url"]; ok {
+		parts = append(parts, fmt.Sprint(url))
+	}
+	if duration, ok := fields[DurationField]; ok {
+		parts = append(parts, fmt.Sprintf("%vms", duration))
+	}
+	return strings.Join(parts, " ")
+}
+
+func writeStackLines(b *bytes.Buffer, stack string) {
+	frames := stacktraceFramePattern.FindAllStringSubmatch(stack, -1)
+	if len(frames) > 0 {
+		for _, frame := range frames {
+			file := frame[4]
+			if file == "" {
+				file = frame[3]
+			}
+			fmt.Fprintf(b, "        %s.%s\n            %s:%s\n", frame[2], frame[1], file, frame[5])
+		}
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+		b.WriteString("        ")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+}
+
+func consoleLevel(level logrus.Level) string {
+	if level == logrus.WarnLevel {
+		return "WARN"
+	}
+	return strings.ToUpper(level.String())
+}
+
+func levelColor(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return colorGray
+	case logrus.InfoLevel:
+		return colorBlue
+	case logrus.WarnLevel:
+		return colorYellow
+	default:
+		return colorRed
+	}
+}
+
+// isTerminal reports whether w writes to a terminal.
+func isTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return term.IsTerminal(int(file.Fd()))
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ConsoleFormatter(t *testing.T) {
+	f := &ConsoleFormatter{DisableColors: true}
+
+	entry := logrus.WithFields(logrus.Fields{"b": 2, "a": "one two"})
+	entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	entry.Level = logrus.WarnLevel
+	entry.Message = "something happened"
+
+	b, err := f.Format(entry)
+	require.NoError(t, err)
+
+	assert.Equal(t, "03:04:05.006 WARN  something happened                           a=\"one two\" b=2\n", string(b))
+}
+
+func Test_ConsoleFormatter_Colors(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "failed"
+
+	b, err := (&ConsoleFormatter{}).Format(entry)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(b), colorRed+"ERROR"+colorReset)
+}
+
+func Test_ConsoleFormatter_CompactHTTPLayout(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+	logger.Formatter = &ConsoleFormatter{DisableColors: true}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/path", nil)
+	logger.Access(r, time.Now().Add(-12*time.Millisecond), http.StatusOK)
+
+	line := b.String()
+	assert.Contains(t, line, "INFO  200 GET /path 12ms")
+	assert.NotContains(t, line, "response_status")
+	assert.Contains(t, line, "host=www.example.org")
+}
+
+func Test_ConsoleFormatter_ExpandsStacktraces(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+	logger.Formatter = &ConsoleFormatter{DisableColors: true}
+
+	logger.WithField("stacktrace", fmt.Sprintf("%+v", NewStacktrace())).
+		WithField("stack", "goroutine 1 [running]:\nmain.main()").
+		Error("failed")
+
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	assert.Contains(t, lines[0], "ERROR failed")
+	assert.NotContains(t, lines[0], "stack")
+	assert.Contains(t, b.String(), "    stacktrace:\n        github.com/snabble/go-logging/v2.")
+	assert.Regexp(t, `\n            /.*console_formatter_test\.go:\d+\n`, b.String())
+	assert.Contains(t, b.String(), "    stack:\n        goroutine 1 [running]:\n        main.main()\n")
+}
+
+func Test_NewLogger_ConsoleLoggingRequiresTerminal(t *testing.T) {
+	logger, err := NewLogger("info", &LogConfig{EnableConsoleLogging: true, Output: bytes.NewBuffer(nil)})
+	require.NoError(t, err)
+	assert.IsType(t, &LogstashFormatter{}, logger.Formatter)
+
+	devNull, err := os.Open(os.DevNull)
+	require.NoError(t, err)
+	defer devNull.Close()
+
+	logger, err = NewLogger("info", &LogConfig{EnableConsoleLogging: true, Output: devNull})
+	require.NoError(t, err)
+	assert.IsType(t, &LogstashFormatter{}, logger.Formatter)
+}
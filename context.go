@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"context"
+	"maps"
+
+	"github.com/sirupsen/logrus"
+)
+
+type entryContextKey struct{}
+
+// ContextWithEntry returns a copy of ctx carrying the entry. Entries obtained with EntryFromContext
+// and the entries of the helpers like Call and Access inherit its fields.
+func ContextWithEntry(ctx context.Context, entry *Entry) context.Context {
+	return context.WithValue(ctx, entryContextKey{}, entry)
+}
+
+// EntryFromContext returns the entry stored with ContextWithEntry bound to ctx. If there is none,
+// a new entry of the global Log is returned.
+func EntryFromContext(ctx context.Context) *Entry {
+	if entry, ok := ctx.Value(entryContextKey{}).(*Entry); ok {
+		return entry.WithContext(ctx)
+	}
+	return Log.WithContext(ctx)
+}
+
+// entryFromContext returns a new entry of the logger bound to ctx, with the fields of the entry
+// stored in ctx if available.
+func (l *Logger) entryFromContext(ctx context.Context) *Entry {
+	entry := l.WithContext(ctx)
+	if ctxEntry, ok := ctx.Value(entryContextKey{}).(*Entry); ok {
+		return entry.WithFields(ctxEntry.Data)
+	}
+	return entry
+}
+
+type fieldsContextKey struct{}
+
+// ContextWithProject returns a copy of ctx carrying the project id, see ContextFields.
+func ContextWithProject(ctx context.Context, projectID string) context.Context {
+	return contextWithField(ctx, ProjectField, projectID)
+}
+
+// ContextWithShop returns a copy of ctx carrying the shop id, see ContextFields.
+func ContextWithShop(ctx context.Context, shopID string) context.Context {
+	return contextWithField(ctx, ShopField, shopID)
+}
+
+// ContextWithCheckout returns a copy of ctx carrying the checkout id, see ContextFields.
+func ContextWithCheckout(ctx context.Context, checkoutID string) context.Context {
+	return contextWithField(ctx, CheckoutField, checkoutID)
+}
+
+// ContextWithCheckoutDevice returns a copy of ctx carrying the checkout device id, see ContextFields.
+func ContextWithCheckoutDevice(ctx context.Context, checkoutDeviceID string) context.Context {
+	return contextWithField(ctx, CheckoutDeviceField, checkoutDeviceID)
+}
+
+// ContextWithOrder returns a copy of ctx carrying the order id, see ContextFields.
+func ContextWithOrder(ctx context.Context, orderID string) context.Context {
+	return contextWithField(ctx, OrderField, orderID)
+}
+
+// ContextWithTransaction returns a copy of ctx carrying the transaction id, see ContextFields.
+func ContextWithTransaction(ctx context.Context, txnID string) context.Context {
+	return contextWithField(ctx, TransactionField, txnID)
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request id, see ContextFields and ForwardRequestID.
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return contextWithField(ctx, RequestIDField, requestID)
+}
+
+// RequestIDFromContext returns the request id stored in ctx by ContextWithRequestID or the LogMiddleware,
+// or an empty string if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	if stored, ok := ctx.Value(fieldsContextKey{}).(map[string]string); ok {
+		return stored[RequestIDField]
+	}
+	return ""
+}
+
+// ContextFields returns the identifiers stored in ctx by ContextWithProject and the related functions.
+// Loggers created by NewLogger add them to every entry bound to the context with WithContext.
+func ContextFields(ctx context.Context) logrus.Fields {
+	fields := logrus.Fields{}
+	if stored, ok := ctx.Value(fieldsContextKey{}).(map[string]string); ok {
+		for key, value := range stored {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func contextWithField(ctx context.Context, key, value string) context.Context {
+	fields := map[string]string{}
+	if stored, ok := ctx.Value(fieldsContextKey{}).(map[string]string); ok {
+		maps.Copy(fields, stored)
+	}
+	fields[key] = value
+
+	return context.WithValue(ctx, fieldsContextKey{}, fields)
+}
+
+// contextFieldsHook adds the identifiers stored in the context of an entry, fields set on the
+// entry itself take precedence.
+type contextFieldsHook struct{}
+
+func (h *contextFieldsHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *contextFieldsHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	stored, ok := entry.Context.Value(fieldsContextKey{}).(map[string]string)
+	if !ok {
+		return nil
+	}
+
+	for key, value := range stored {
+		if _, exists := entry.Data[key]; !exists {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
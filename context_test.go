@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EntryFromContext_FallsBackToLog(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	EntryFromContext(context.Background()).Info("message")
+
+	assert.Equal(t, "message", logRecordFromBuffer(b).Message)
+}
+
+func Test_EntryFromContext_InheritsFields(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	ctx := ContextWithEntry(context.Background(), Log.WithProject("__project__"))
+	EntryFromContext(ctx).WithShop("__shop__").Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "__project__", data[ProjectField])
+	assert.Equal(t, "__shop__", data[ShopField])
+}
+
+func Test_Call_InheritsFieldsFromContextEntry(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	ctx := ContextWithEntry(context.Background(), Log.WithCheckout("__checkout__"))
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://www.example.org/foo", nil)
+
+	Call(r, &http.Response{StatusCode: http.StatusOK}, time.Now(), nil)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "call", data[TypeField])
+	assert.Equal(t, "__checkout__", data[CheckoutField])
+}
+
+func Test_LogMiddleware_SeedsContextWithEntry(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	lm := NewLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.Context().Value(entryContextKey{}).(*Entry)
+		assert.True(t, ok)
+
+		EntryFromContext(r.Context()).Info("from handler")
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+
+	records := logRecordsFromBuffer(b)
+	assert.Equal(t, "from handler", records[0].Message)
+	assert.Equal(t, "200 ->GET /foo", records[1].Message)
+}
+
+func Test_ContextFields_AddedToEntriesWithContext(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	ctx := ContextWithProject(context.Background(), "__project__")
+	ctx = ContextWithShop(ctx, "__shop__")
+	ctx = ContextWithCheckout(ctx, "__checkout__")
+	ctx = ContextWithCheckoutDevice(ctx, "__device__")
+	ctx = ContextWithOrder(ctx, "__order__")
+	ctx = ContextWithTransaction(ctx, "__transaction__")
+
+	Log.WithContext(ctx).WithShop("__explicit_shop__").Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "__project__", data[ProjectField])
+	assert.Equal(t, "__explicit_shop__", data[ShopField])
+	assert.Equal(t, "__checkout__", data[CheckoutField])
+	assert.Equal(t, "__device__", data[CheckoutDeviceField])
+	assert.Equal(t, "__order__", data[OrderField])
+	assert.Equal(t, "__transaction__", data[TransactionField])
+}
+
+func Test_ContextFields_DoNotLeakIntoParentContext(t *testing.T) {
+	parent := ContextWithProject(context.Background(), "__project__")
+	child := ContextWithShop(parent, "__shop__")
+
+	assert.Equal(t, logrus.Fields{ProjectField: "__project__"}, ContextFields(parent))
+	assert.Equal(t, logrus.Fields{ProjectField: "__project__", ShopField: "__shop__"}, ContextFields(child))
+}
+
+func Test_Access_CarriesContextFields(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	ctx := ContextWithProject(context.Background(), "__project__")
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://www.example.org/foo", nil)
+
+	Access(r, time.Now(), http.StatusOK)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "access", data[TypeField])
+	assert.Equal(t, "__project__", data[ProjectField])
+}
+
+func Test_RequestIDFromContext(t *testing.T) {
+	assert.Empty(t, RequestIDFromContext(context.Background()))
+
+	ctx := ContextWithRequestID(ContextWithProject(context.Background(), "__project__"), "__request_id__")
+	assert.Equal(t, "__request_id__", RequestIDFromContext(ctx))
+}
+
+func Test_ForwardRequestID(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	ForwardRequestID(r)
+	assert.Empty(t, r.Header)
+
+	ctx := ContextWithRequestID(context.Background(), "__request_id__")
+	r, _ = http.NewRequestWithContext(ctx, http.MethodGet, "http://www.example.org/foo", nil)
+	ForwardRequestID(r)
+	assert.Equal(t, "__request_id__", r.Header.Get(DefaultRequestIDHeader))
+}
@@ -0,0 +1,113 @@
+package logging
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ECSVersion is the version of the Elastic Common Schema written by the ECSFormatter.
+const ECSVersion = "8.11.0"
+
+// ecsFieldMap maps the fields written by the helpers to their ECS names.
+// https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html
+var ecsFieldMap = map[string]string{
+	TypeField:         "event.dataset",
+	"event":           "event.action",
+	ScopeField:        "log.logger",
+	"method":          "http.request.method",
+	"url":             "url.original",
+	"full_url":        "url.full",
+	"host":            "url.domain",
+	RemoteIPField:     "client.ip",
+	"User_Agent":      "user_agent.original",
+	"response_status": "http.response.status_code",
+	"content_length":  "http.request.body.bytes",
+	"response_size":   "http.response.body.bytes",
+	"content_type":    "http.response.mime_type",
+	RequestIDField:    "http.request.id",
+	logrus.ErrorKey:   "error.message",
+	"stack":           "error.stack_trace",
+}
+
+// ECSFormatter generates json with the field names of the Elastic Common Schema.
+// Fields without an ECS equivalent are written unchanged. The event.duration is written in
+// nanoseconds as required by ECS, but has the millisecond precision of the DurationField.
+type ECSFormatter struct {
+	// TimestampFormat sets the format used for timestamps, defaults to time.RFC3339Nano.
+	TimestampFormat string
+}
+
+func (f *ECSFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	fields := make(logrus.Fields, len(entry.Data)+6)
+
+	timeStampFormat := f.TimestampFormat
+	if timeStampFormat == "" {
+		timeStampFormat = time.RFC3339Nano
+	}
+
+	for k, v := range entry.Data {
+		switch k {
+		case "message", "ecs.version", "log.level":
+			// reserved for the base fields
+			fields["fields."+k] = v
+			continue
+		case "@timestamp":
+			// the start of access and call entries
+			if start, ok := v.(time.Time); ok {
+				v = start.Format(timeStampFormat)
+			}
+			fields["event.start"] = v
+			continue
+		case "User_Agent":
+			if v == "" {
+				continue
+			}
+		case DurationField:
+			if ms, ok := v.(int64); ok {
+				fields["event.duration"] = ms * int64(time.Millisecond)
+				continue
+			}
+		case "stacktrace":
+			// the stack of the access error has priority, the stacktrace is written unchanged then
+			if _, ok := entry.Data["stack"]; !ok {
+				k = "error.stack_trace"
+			}
+		case "proto":
+			if proto, ok := v.(string); ok {
+				fields["http.version"] = strings.TrimPrefix(proto, "HTTP/")
+				continue
+			}
+		}
+
+		if err, ok := v.(error); ok {
+			// Otherwise, errors are ignored by `encoding/json`
+			v = err.Error()
+		}
+
+		if name, ok := ecsFieldMap[k]; ok {
+			k = name
+		}
+		fields[k] = v
+	}
+
+	fields["@timestamp"] = entry.Time.Format(timeStampFormat)
+	fields["message"] = entry.Message
+	fields["log.level"] = entry.Level.String()
+	fields["ecs.version"] = ECSVersion
+
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if spanContext.IsValid() {
+		fields["trace.id"] = spanContext.TraceID().String()
+		fields["span.id"] = spanContext.SpanID().String()
+	}
+
+	encoder := getJSONFields()
+	defer putJSONFields(encoder)
+	for k, v := range fields {
+		encoder.add(jsonField{key: k, value: v})
+	}
+	return append(encoder.appendJSON(nil), '\n'), nil
+}
@@ -0,0 +1,121 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newECSLogger(t *testing.T) (*Logger, *bytes.Buffer) {
+	t.Helper()
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{ElasticCommonSchema: true, Output: b})
+	require.NoError(t, err)
+	return logger, b
+}
+
+func Test_ECSFormatter_Access(t *testing.T) {
+	logger, b := newECSLogger(t)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo?q=bar", nil)
+	r.Header.Set("User-Agent", "__agent__")
+	r.RemoteAddr = "127.0.0.1:1234"
+
+	start := time.Now().Add(-1 * time.Second)
+	logger.Access(r, start, http.StatusCreated)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, ECSVersion, data["ecs.version"])
+	assert.Equal(t, "info", data["log.level"])
+	assert.Equal(t, "201 ->GET /foo?q=bar", data["message"])
+	assert.NotEmpty(t, data["@timestamp"])
+	assert.Equal(t, "access", data["event.dataset"])
+	assert.Equal(t, "GET", data["http.request.method"])
+	assert.Equal(t, "/foo?q=bar", data["url.original"])
+	assert.Equal(t, "www.example.org", data["url.domain"])
+	assert.Equal(t, "127.0.0.1", data["client.ip"])
+	assert.Equal(t, "__agent__", data["user_agent.original"])
+	assert.Equal(t, "1.1", data["http.version"])
+	assert.Equal(t, 201.0, data["http.response.status_code"])
+	assert.InDelta(t, float64(time.Second), data["event.duration"], float64(10*time.Millisecond))
+	assert.NotContains(t, data, "@version")
+	assert.NotContains(t, data, "remote_ip")
+	assert.Equal(t, start.Format(time.RFC3339Nano), data["event.start"])
+	assert.NotContains(t, data, "fields.@timestamp")
+}
+
+func Test_ECSFormatter_Call(t *testing.T) {
+	logger, b := newECSLogger(t)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	logger.Call(r, &http.Response{StatusCode: http.StatusOK, Header: http.Header{"Content-Type": {"text/plain"}}}, time.Now(), nil)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "call", data["event.dataset"])
+	assert.Equal(t, "http://www.example.org/foo", data["url.full"])
+	assert.Equal(t, "text/plain", data["http.response.mime_type"])
+	assert.Equal(t, 0.0, data["http.request.body.bytes"])
+	assert.NotContains(t, data, "user_agent.original")
+	assert.NotContains(t, data, "User_Agent")
+}
+
+func Test_ECSFormatter_LifecycleAndError(t *testing.T) {
+	logger, b := newECSLogger(t)
+
+	logger.LifecycleStop("my-app", nil, errors.New("boom"))
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "error", data["log.level"])
+	assert.Equal(t, "lifecycle", data["event.dataset"])
+	assert.Equal(t, "stop", data["event.action"])
+	assert.Equal(t, "boom", data["error.message"])
+
+	b.Reset()
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	logger.AccessError(r, time.Now(), errors.New("oops"), []byte("__stack__"))
+
+	data = mapFromBuffer(b)
+	assert.Equal(t, "oops", data["error.message"])
+	assert.Equal(t, "__stack__", data["error.stack_trace"])
+}
+
+func Test_ECSFormatter_StackHasPriorityOverStacktrace(t *testing.T) {
+	logger, b := newECSLogger(t)
+
+	for i := 0; i < 10; i++ {
+		logger.WithField("stacktrace", "__stacktrace__").WithField("stack", "__stack__").Error("boom")
+
+		data := mapFromBuffer(b)
+		assert.Equal(t, "__stack__", data["error.stack_trace"])
+		assert.Equal(t, "__stacktrace__", data["stacktrace"])
+		b.Reset()
+	}
+
+	logger.WithField("stacktrace", "__stacktrace__").Error("boom")
+	data := mapFromBuffer(b)
+	assert.Equal(t, "__stacktrace__", data["error.stack_trace"])
+	assert.NotContains(t, data, "stacktrace")
+}
+
+func Test_ECSFormatter_TraceAndCollisions(t *testing.T) {
+	tp := initTracer()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+	logger, b := newECSLogger(t)
+
+	ctx, span := startSpan()
+	defer span.End()
+	logger.WithContext(ctx).WithField("message", "__shadowed__").WithScope("payment").Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, span.SpanContext().TraceID().String(), data["trace.id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), data["span.id"])
+	assert.Equal(t, "message", data["message"])
+	assert.Equal(t, "__shadowed__", data["fields.message"])
+	assert.Equal(t, "payment", data["log.logger"])
+}
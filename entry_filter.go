@@ -0,0 +1,128 @@
+package logging
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// entryFilter decides whether an entry is logged before the hooks fire. It is the only hook of the
+// logrus.Logger and fires the hooks added with Logger.AddHook for the accepted entries, so rejected
+// entries are neither seen by hooks like the OTel export nor written by the formatter. Holding the
+// hooks itself also allows Logger.RemoveHook, which logrus does not provide.
+//
+// Rejected entries are marked in their context and dropped by the filterFormatter, so it must wrap the
+// formatter of the logrus.Logger. Logger.SetFormatter keeps it, but assigning the Formatter of the
+// logrus.Logger directly removes the filtering of the output.
+type entryFilter struct {
+	scopes  *scopeLevels
+	sampler *sampler
+
+	mu sync.Mutex
+	// hooks is replaced on changes, so Fire can use it without copying
+	hooks logrus.LevelHooks
+}
+
+// rejectedKey marks the context of entries rejected by the entryFilter.
+type rejectedKey struct{}
+
+func newEntryFilter(scopes *scopeLevels, sampler *sampler) *entryFilter {
+	return &entryFilter{scopes: scopes, sampler: sampler, hooks: logrus.LevelHooks{}}
+}
+
+// filters reports whether entries are filtered at all, the filter may be nil.
+func (f *entryFilter) filters() bool {
+	return f != nil && (f.scopes != nil || f.sampler != nil)
+}
+
+func (f *entryFilter) add(hook logrus.Hook) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	hooks := logrus.LevelHooks{}
+	for level, levelHooks := range f.hooks {
+		hooks[level] = slices.Clip(levelHooks)
+	}
+	hooks.Add(hook)
+	f.hooks = hooks
+}
+
+func (f *entryFilter) remove(hook logrus.Hook) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.hooks = withoutHook(f.hooks, hook)
+}
+
+// accept decides whether the entry is logged and adds the number of entries suppressed by the sampling.
+// Entries filtered by their scope must not be counted by the sampling, so the scope is checked first.
+func (f *entryFilter) accept(entry *logrus.Entry) bool {
+	if f.scopes != nil && !f.scopes.isEnabled(entry) {
+		return false
+	}
+	if f.sampler == nil {
+		return true
+	}
+
+	keep, suppressed := f.sampler.sample(entry)
+	if _, exists := entry.Data[CountField]; keep && suppressed > 0 && !exists {
+		entry.Data[CountField] = suppressed
+	}
+	return keep
+}
+
+func (f *entryFilter) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (f *entryFilter) Fire(entry *logrus.Entry) error {
+	if !f.accept(entry) {
+		// the entry is a copy made by logrus for this log call, so the mark does not leak to other entries
+		ctx := entry.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		entry.Context = context.WithValue(ctx, rejectedKey{}, true)
+		return nil
+	}
+
+	f.mu.Lock()
+	hooks := f.hooks[entry.Level]
+	f.mu.Unlock()
+
+	// like logrus.LevelHooks.Fire
+	for _, hook := range hooks {
+		if err := hook.Fire(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withoutHook returns a copy of the hooks without hook.
+func withoutHook(hooks logrus.LevelHooks, hook logrus.Hook) logrus.LevelHooks {
+	result := logrus.LevelHooks{}
+	for level, levelHooks := range hooks {
+		result[level] = slices.DeleteFunc(slices.Clone(levelHooks), func(h logrus.Hook) bool {
+			return h == hook
+		})
+	}
+	return result
+}
+
+func isRejected(entry *logrus.Entry) bool {
+	return entry.Context != nil && entry.Context.Value(rejectedKey{}) != nil
+}
+
+// filterFormatter drops the entries rejected by the entryFilter, it is the outermost formatter.
+type filterFormatter struct {
+	logrus.Formatter
+}
+
+func (f *filterFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if isRejected(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
@@ -0,0 +1,152 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Environment variables read by LogConfigFromEnv and SetFromEnv.
+const (
+	// EnvLogLevel sets the log level (trace, debug, info, warning, error, fatal, panic), defaults to info.
+	EnvLogLevel = "LOG_LEVEL"
+	// EnvLogFormat selects the output format (logstash, text, logfmt, console, google, ecs), defaults to logstash.
+	EnvLogFormat = "LOG_FORMAT"
+	// EnvLogTraces enables or disables the trace and span fields, defaults to true.
+	EnvLogTraces = "LOG_TRACES"
+	// EnvLogLevelForServerError sets the level used for access logs with status 500, defaults to error.
+	EnvLogLevelForServerError = "LOG_LEVEL_SERVER_ERROR"
+	// EnvLogOutput selects the output target (stdout, stderr, file:<path>), defaults to stderr.
+	EnvLogOutput = "LOG_OUTPUT"
+	// EnvLogScopeLevels sets levels per scope, e.g. "payment=debug,cache=warn", see ParseScopeLevels.
+	EnvLogScopeLevels = "LOG_SCOPE_LEVELS"
+)
+
+const (
+	LogFormatLogstash = "logstash"
+	LogFormatText     = "text"
+	LogFormatLogfmt   = "logfmt"
+	LogFormatConsole  = "console"
+	LogFormatGoogle   = "google"
+	LogFormatECS      = "ecs"
+)
+
+const defaultLogLevel = "info"
+
+// SetFromEnv configures the Logger based on the environment variables documented at EnvLogLevel and
+// the following constants.
+func SetFromEnv() error {
+	level, config, err := LogConfigFromEnv()
+	if err != nil {
+		return err
+	}
+
+	return SetWithConfig(level, config)
+}
+
+// LogConfigFromEnv reads the log level and the LogConfig from the environment. All invalid values are
+// reported together in the returned error.
+func LogConfigFromEnv() (string, *LogConfig, error) {
+	var errs []error
+
+	level := defaultLogLevel
+	if value, ok := lookupEnv(EnvLogLevel); ok {
+		if _, err := logrus.ParseLevel(value); err != nil {
+			errs = append(errs, envError(EnvLogLevel, value, err))
+		} else {
+			level = value
+		}
+	}
+
+	config := &LogConfig{EnableTraces: true}
+
+	if value, ok := lookupEnv(EnvLogFormat); ok {
+		switch strings.ToLower(value) {
+		case LogFormatLogstash:
+		case LogFormatText:
+			config.EnableTextLogging = true
+		case LogFormatLogfmt:
+			config.EnableLogfmtLogging = true
+		case LogFormatConsole:
+			config.EnableConsoleLogging = true
+		case LogFormatGoogle:
+			config.GoogleCloudLogging = true
+		case LogFormatECS:
+			config.ElasticCommonSchema = true
+		default:
+			errs = append(errs, envError(EnvLogFormat, value,
+				fmt.Errorf("expected one of %s, %s, %s, %s, %s, %s",
+					LogFormatLogstash, LogFormatText, LogFormatLogfmt, LogFormatConsole, LogFormatGoogle, LogFormatECS)))
+		}
+	}
+
+	if value, ok := lookupEnv(EnvLogTraces); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, envError(EnvLogTraces, value, err))
+		} else {
+			config.EnableTraces = enabled
+		}
+	}
+
+	if value, ok := lookupEnv(EnvLogLevelForServerError); ok {
+		serverErrorLevel, err := logrus.ParseLevel(value)
+		if err != nil {
+			errs = append(errs, envError(EnvLogLevelForServerError, value, err))
+		} else {
+			config.LogLevelForServerError = &serverErrorLevel
+		}
+	}
+
+	if value, ok := lookupEnv(EnvLogOutput); ok {
+		output, err := outputFromEnv(value)
+		if path, isFile := strings.CutPrefix(value, "file:"); isFile && path != "" {
+			config.OutputFile = &FileConfig{Path: path}
+		} else if err != nil {
+			errs = append(errs, envError(EnvLogOutput, value, err))
+		} else {
+			config.Output = output
+		}
+	}
+
+	if value, ok := lookupEnv(EnvLogScopeLevels); ok {
+		scopeLevels, err := ParseScopeLevels(value)
+		if err != nil {
+			errs = append(errs, envError(EnvLogScopeLevels, value, err))
+		} else {
+			config.ScopeLevels = scopeLevels
+		}
+	}
+
+	if len(errs) > 0 {
+		return "", nil, errors.Join(errs...)
+	}
+
+	return level, config, nil
+}
+
+func outputFromEnv(value string) (io.Writer, error) {
+	switch strings.ToLower(value) {
+	case "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	}
+
+	return nil, errors.New("expected one of stdout, stderr, file:<path>")
+}
+
+func lookupEnv(key string) (string, bool) {
+	value, ok := os.LookupEnv(key)
+	value = strings.TrimSpace(value)
+	return value, ok && value != ""
+}
+
+func envError(key, value string, err error) error {
+	return fmt.Errorf("invalid value '%s' for %s: %w", value, key, err)
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clearLogEnv unsets the variables read by LogConfigFromEnv, which may be set in CI or by developers.
+func clearLogEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range []string{EnvLogLevel, EnvLogFormat, EnvLogTraces, EnvLogLevelForServerError, EnvLogOutput, EnvLogScopeLevels} {
+		// empty values are treated as unset
+		t.Setenv(key, "")
+	}
+}
+
+func Test_LogConfigFromEnv_Defaults(t *testing.T) {
+	clearLogEnv(t)
+
+	level, config, err := LogConfigFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "info", level)
+	assert.True(t, config.EnableTraces)
+	assert.False(t, config.EnableTextLogging)
+	assert.False(t, config.GoogleCloudLogging)
+	assert.Nil(t, config.LogLevelForServerError)
+	assert.Nil(t, config.Output)
+	assert.Nil(t, config.ScopeLevels)
+}
+
+func Test_LogConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvLogLevel, "debug")
+	t.Setenv(EnvLogFormat, "google")
+	t.Setenv(EnvLogTraces, "false")
+	t.Setenv(EnvLogLevelForServerError, "warn")
+	t.Setenv(EnvLogOutput, "stdout")
+
+	level, config, err := LogConfigFromEnv()
+	require.NoError(t, err)
+
+	assert.Equal(t, "debug", level)
+	assert.False(t, config.EnableTraces)
+	assert.True(t, config.GoogleCloudLogging)
+	assert.Equal(t, logrus.WarnLevel, *config.LogLevelForServerError)
+	assert.Equal(t, os.Stdout, config.Output)
+}
+
+func Test_LogConfigFromEnv_OutputFile(t *testing.T) {
+	t.Setenv(EnvLogOutput, "file:/var/log/app.log")
+
+	_, config, err := LogConfigFromEnv()
+	require.NoError(t, err)
+
+	assert.Nil(t, config.Output)
+	assert.Equal(t, &FileConfig{Path: "/var/log/app.log"}, config.OutputFile)
+}
+
+func Test_LogConfigFromEnv_ReportsAllInvalidValues(t *testing.T) {
+	t.Setenv(EnvLogLevel, "verbose")
+	t.Setenv(EnvLogFormat, "xml")
+	t.Setenv(EnvLogTraces, "maybe")
+	t.Setenv(EnvLogLevelForServerError, "loud")
+	t.Setenv(EnvLogOutput, "/dev/null")
+
+	_, _, err := LogConfigFromEnv()
+	require.Error(t, err)
+
+	for _, key := range []string{EnvLogLevel, EnvLogFormat, EnvLogTraces, EnvLogLevelForServerError, EnvLogOutput} {
+		assert.Contains(t, err.Error(), key)
+	}
+}
+
+func Test_SetFromEnv(t *testing.T) {
+	defer Set("info", true)
+	t.Setenv(EnvLogLevel, "warn")
+	t.Setenv(EnvLogFormat, "text")
+
+	require.NoError(t, SetFromEnv())
+
+	assert.Equal(t, logrus.WarnLevel, Log.Level)
+	assert.IsType(t, &logrus.TextFormatter{}, Log.Formatter)
+}
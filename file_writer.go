@@ -0,0 +1,342 @@
+package logging
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backupTimeFormat is part of the names of rotated files, e.g. app-2024-01-02T03-04-05.000.log.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileConfig configures a FileWriter.
+type FileConfig struct {
+	// Path is the log file, its directory is created if missing.
+	Path string
+	// MaxSize rotates the file before it exceeds this number of bytes, it is not rotated by size if 0.
+	MaxSize int64
+	// RotationInterval rotates the file after this duration, it is not rotated by time if 0.
+	RotationInterval time.Duration
+	// Compress gzips the rotated files.
+	Compress bool
+	// MaxBackups is the number of rotated files kept, all are kept if 0.
+	MaxBackups int
+	// MaxAge removes rotated files older than this, they are kept if 0.
+	MaxAge time.Duration
+	// ReopenOnSIGHUP reopens the file on SIGHUP, which is sent by logrotate after moving the file.
+	// It changes the signal handling of the process, SIGHUP no longer terminates it.
+	ReopenOnSIGHUP bool
+}
+
+// FileWriter writes to a log file, which is rotated by size and time. The rotated files are named after
+// the file with the time of the rotation, e.g. app-2024-01-02T03-04-05.000.log or
+// app-2024-01-02T03-04-05.000.log.gz if compressed.
+type FileWriter struct {
+	config FileConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	hup  chan os.Signal
+	done chan struct{}
+
+	// mill compresses and removes the rotated files in the background
+	millMu sync.Mutex
+	wg     sync.WaitGroup
+}
+
+// NewFileWriter opens the file of the config for appending.
+func NewFileWriter(config FileConfig) (*FileWriter, error) {
+	if config.Path == "" {
+		return nil, errors.New("missing path of the log file")
+	}
+
+	config.Path = filepath.Clean(config.Path)
+	w := &FileWriter{config: config, now: time.Now, done: make(chan struct{})}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	if config.ReopenOnSIGHUP {
+		w.hup = make(chan os.Signal, 1)
+		signal.Notify(w.hup, syscall.SIGHUP)
+		go w.reopenOnSignal()
+	}
+	return w, nil
+}
+
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the file, renames it and opens a new file.
+func (w *FileWriter) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+// Reopen closes and opens the file, e.g. after it is moved by logrotate.
+func (w *FileWriter) Reopen() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	return w.open()
+}
+
+// Close closes the file, stops the handling of SIGHUP and waits for the compression of the rotated files.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	if w.file == nil {
+		w.mu.Unlock()
+		return nil
+	}
+
+	if w.hup != nil {
+		signal.Stop(w.hup)
+	}
+	close(w.done)
+
+	err := w.file.Close()
+	w.file = nil
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	return err
+}
+
+func (w *FileWriter) reopenOnSignal() {
+	for {
+		select {
+		case <-w.hup:
+			if err := w.Reopen(); err != nil && !errors.Is(err, os.ErrClosed) {
+				fmt.Fprintf(os.Stderr, "Failed to reopen log file, %v\n", err)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+func (w *FileWriter) open() error {
+	if err := os.MkdirAll(filepath.Dir(w.config.Path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(w.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	w.file = file
+	w.size = info.Size()
+	w.openedAt = w.now()
+	return nil
+}
+
+func (w *FileWriter) shouldRotate(n int) bool {
+	if w.config.MaxSize > 0 && w.size > 0 && w.size+int64(n) > w.config.MaxSize {
+		return true
+	}
+	return w.config.RotationInterval > 0 && !w.now().Before(w.openedAt.Add(w.config.RotationInterval))
+}
+
+// rotate must be called with the lock held.
+func (w *FileWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+
+	now := w.now()
+	backup := w.backupName(now)
+	if err := os.Rename(w.config.Path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		w.mill(now)
+	}()
+	return nil
+}
+
+func (w *FileWriter) backupName(t time.Time) string {
+	prefix, ext := w.backupPrefixAndExt()
+	name := prefix + t.UTC().Format(backupTimeFormat) + ext
+	for i := 1; fileExists(name) || fileExists(name+".gz"); i++ {
+		name = fmt.Sprintf("%s%s-%d%s", prefix, t.UTC().Format(backupTimeFormat), i, ext)
+	}
+	return name
+}
+
+func (w *FileWriter) backupPrefixAndExt() (string, string) {
+	ext := filepath.Ext(w.config.Path)
+	return strings.TrimSuffix(w.config.Path, ext) + "-", ext
+}
+
+type backupFile struct {
+	path      string
+	rotatedAt time.Time
+	// counter distinguishes files rotated within the same millisecond
+	counter    int
+	compressed bool
+}
+
+// backups returns the rotated files, the newest first.
+func (w *FileWriter) backups() ([]backupFile, error) {
+	prefix, ext := w.backupPrefixAndExt()
+	entries, err := os.ReadDir(filepath.Dir(w.config.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []backupFile
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(w.config.Path), entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(path, prefix) {
+			continue
+		}
+
+		backup := backupFile{path: path}
+		name := strings.TrimPrefix(path, prefix)
+		if strings.HasSuffix(name, ext+".gz") {
+			backup.compressed = true
+			name = strings.TrimSuffix(name, ext+".gz")
+		} else if strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext)
+		} else {
+			continue
+		}
+
+		if len(name) < len(backupTimeFormat) {
+			continue
+		}
+		rotatedAt, err := time.Parse(backupTimeFormat, name[:len(backupTimeFormat)])
+		if err != nil {
+			continue
+		}
+		backup.rotatedAt = rotatedAt
+		if counter, ok := strings.CutPrefix(name[len(backupTimeFormat):], "-"); ok {
+			if backup.counter, err = strconv.Atoi(counter); err != nil {
+				continue
+			}
+		}
+		backups = append(backups, backup)
+	}
+
+	slices.SortFunc(backups, func(a, b backupFile) int {
+		if c := b.rotatedAt.Compare(a.rotatedAt); c != 0 {
+			return c
+		}
+		return b.counter - a.counter
+	})
+	return backups, nil
+}
+
+// mill compresses the rotated files and removes the files exceeding the retention at now.
+func (w *FileWriter) mill(now time.Time) {
+	w.millMu.Lock()
+	defer w.millMu.Unlock()
+
+	backups, err := w.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list rotated log files, %v\n", err)
+		return
+	}
+
+	for i, backup := range backups {
+		expired := w.config.MaxBackups > 0 && i >= w.config.MaxBackups ||
+			w.config.MaxAge > 0 && now.Sub(backup.rotatedAt) > w.config.MaxAge
+		if expired {
+			if err := os.Remove(backup.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				fmt.Fprintf(os.Stderr, "Failed to remove rotated log file, %v\n", err)
+			}
+			continue
+		}
+
+		if w.config.Compress && !backup.compressed {
+			if err := compressFile(backup.path); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to compress rotated log file, %v\n", err)
+			}
+		}
+	}
+}
+
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	if err := errors.Join(gz.Close(), out.Close()); err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+
+	_ = in.Close()
+	return os.Remove(path)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,207 @@
+package logging
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FileWriter_RotatesBySizeAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w, err := NewFileWriter(FileConfig{Path: path, MaxSize: 10, Compress: true})
+	require.NoError(t, err)
+	clock := fakeClock(w)
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+		clock.Add(time.Second)
+	}
+	require.NoError(t, w.Close())
+
+	// the files are named after the time of the rotation
+	assert.Equal(t, []string{
+		"app-2024-01-02T03-04-06.000.log.gz",
+		"app-2024-01-02T03-04-07.000.log.gz",
+		"app.log",
+	}, fileNames(t, dir))
+	assert.Equal(t, "first\n", gunzip(t, filepath.Join(dir, "app-2024-01-02T03-04-06.000.log.gz")))
+	assert.Equal(t, "second\n", gunzip(t, filepath.Join(dir, "app-2024-01-02T03-04-07.000.log.gz")))
+	assert.Equal(t, "third\n", readFile(t, path))
+}
+
+func Test_FileWriter_RotatesByTime(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w, err := NewFileWriter(FileConfig{Path: path, RotationInterval: time.Hour})
+	require.NoError(t, err)
+	clock := fakeClock(w)
+	require.NoError(t, w.Reopen())
+
+	_, err = w.Write([]byte("first\n"))
+	require.NoError(t, err)
+	clock.Add(59 * time.Minute)
+	_, err = w.Write([]byte("second\n"))
+	require.NoError(t, err)
+	clock.Add(time.Minute)
+	_, err = w.Write([]byte("third\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{"app-2024-01-02T04-04-05.000.log", "app.log"}, fileNames(t, dir))
+	assert.Equal(t, "first\nsecond\n", readFile(t, filepath.Join(dir, "app-2024-01-02T04-04-05.000.log")))
+	assert.Equal(t, "third\n", readFile(t, path))
+}
+
+func Test_FileWriter_Retention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	// rotated files of an earlier run, unrelated files are kept
+	for _, name := range []string{"app-2023-12-01T00-00-00.000.log.gz", "app-2024-01-02T00-00-00.000.log", "app-server.log", "other.log"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("old\n"), 0o644))
+	}
+
+	w, err := NewFileWriter(FileConfig{Path: path, MaxBackups: 2, MaxAge: 7 * 24 * time.Hour})
+	require.NoError(t, err)
+	fakeClock(w)
+
+	for range 3 {
+		_, err := w.Write([]byte("line\n"))
+		require.NoError(t, err)
+		require.NoError(t, w.Rotate())
+	}
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, []string{
+		"app-2024-01-02T03-04-05.000-1.log",
+		"app-2024-01-02T03-04-05.000-2.log",
+		"app-server.log",
+		"app.log",
+		"other.log",
+	}, fileNames(t, dir))
+}
+
+func Test_FileWriter_ReopensOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+
+	w, err := NewFileWriter(FileConfig{Path: path, ReopenOnSIGHUP: true})
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("before\n"))
+	require.NoError(t, err)
+
+	// logrotate moves the file and sends SIGHUP
+	require.NoError(t, os.Rename(path, path+".1"))
+	w.hup <- syscall.SIGHUP
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, time.Millisecond)
+
+	_, err = w.Write([]byte("after\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "before\n", readFile(t, path+".1"))
+	assert.Equal(t, "after\n", readFile(t, path))
+}
+
+func Test_FileWriter_AsLogConfigOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "app.log")
+
+	logger, err := NewLogger("info", &LogConfig{OutputFile: &FileConfig{Path: path}})
+	require.NoError(t, err)
+	logger.Info("message")
+	require.NoError(t, logger.Close())
+
+	assert.Contains(t, readFile(t, path), `"message":"message"`)
+
+	// the file is closed
+	_, err = logger.Out.Write([]byte("after close"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	assert.NoError(t, logger.Close())
+}
+
+func Test_FileWriter_WithoutSIGHUPByDefault(t *testing.T) {
+	w, err := NewFileWriter(FileConfig{Path: filepath.Join(t.TempDir(), "app.log")})
+	require.NoError(t, err)
+	defer w.Close()
+
+	assert.Nil(t, w.hup)
+}
+
+func Test_SetWithConfig_ClosesPreviousLogFile(t *testing.T) {
+	defer Set("info", true)
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	require.NoError(t, SetWithConfig("info", &LogConfig{OutputFile: &FileConfig{Path: path, ReopenOnSIGHUP: true}}))
+	previous := Log.Out.(*FileWriter)
+
+	require.NoError(t, Set("info", true))
+
+	_, err := previous.Write([]byte("after close"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Add(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// fakeClock replaces the clock of w, which starts at 2024-01-02T03:04:05Z.
+func fakeClock(w *FileWriter) *testClock {
+	clock := &testClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	w.mu.Lock()
+	w.now = func() time.Time { return clock.now }
+	w.mu.Unlock()
+	return clock
+}
+
+func fileNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func gunzip(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	return string(content)
+}
@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Special fields of Google Cloud Logging
+// https://cloud.google.com/logging/docs/structured-logging#structured_logging_special_fields
+const (
+	googleTraceField          = "logging.googleapis.com/trace"
+	googleSpanIDField         = "logging.googleapis.com/spanId"
+	googleTraceSampledField   = "logging.googleapis.com/trace_sampled"
+	googleSourceLocationField = "logging.googleapis.com/sourceLocation"
+	googleHTTPRequestField    = "httpRequest"
+)
+
+// loggingPackage is the import path of this package, its frames are skipped for the source location.
+var loggingPackage = reflect.TypeOf(Logger{}).PkgPath()
+
+// sourceLocationHook sets the caller of entries for the source location of Google Cloud Logging.
+// Unlike logrus.Logger.SetReportCaller, it skips the frames of this package, so entries logged by
+// helpers like Lifecycle point to their callers, and it does not look up the caller for access and
+// call entries, which have no meaningful source location.
+type sourceLocationHook struct{}
+
+func (h *sourceLocationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *sourceLocationHook) Fire(entry *logrus.Entry) error {
+	if t := entry.Data[TypeField]; t == TypeAccess || t == TypeCall {
+		return nil
+	}
+	entry.Caller = callerFrame()
+	return nil
+}
+
+// callerFrame returns the first frame outside of logrus and this package.
+func callerFrame() *runtime.Frame {
+	pcs := make([]uintptr, 32)
+	// skip runtime.Callers, callerFrame and sourceLocationHook.Fire
+	n := runtime.Callers(3, pcs)
+
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLoggingFrame(frame) {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+func isLoggingFrame(frame runtime.Frame) bool {
+	// the tests of this package are callers like any other
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	pkg := packageName(frame.Function)
+	return pkg == "github.com/sirupsen/logrus" || pkg == loggingPackage || strings.HasPrefix(pkg, loggingPackage+"/")
+}
+
+// googleSeverity maps the logrus levels to the severities of Google Cloud Logging.
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#logseverity
+func googleSeverity(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.PanicLevel:
+		return "CRITICAL"
+	case logrus.FatalLevel:
+		return "EMERGENCY"
+	}
+	return "DEFAULT"
+}
+
+func addGoogleFields(entry *logrus.Entry, fields *jsonFields, projectID string) {
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if spanContext.IsValid() && projectID != "" {
+		fields.setString(googleTraceField, fmt.Sprintf("projects/%s/traces/%s", projectID, spanContext.TraceID()))
+		fields.setString(googleSpanIDField, spanContext.SpanID().String())
+		fields.set(googleTraceSampledField, spanContext.IsSampled())
+	}
+
+	if entry.Caller != nil {
+		fields.set(googleSourceLocationField, map[string]any{
+			"file":     filepath.Base(entry.Caller.File),
+			"line":     fmt.Sprint(entry.Caller.Line),
+			"function": entry.Caller.Function,
+		})
+	}
+
+	if t, _ := fields.get(TypeField); t == TypeAccess || t == TypeCall {
+		fields.set(googleHTTPRequestField, googleHTTPRequest(fields))
+	}
+}
+
+// googleHTTPRequest builds the HttpRequest object from the fields of access and call entries.
+// https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#httprequest
+func googleHTTPRequest(fields *jsonFields) map[string]any {
+	httpRequest := map[string]any{}
+
+	copyField := func(from, to string) {
+		if v, ok := fields.get(from); ok && v != "" {
+			httpRequest[to] = v
+		}
+	}
+
+	copyField("method", "requestMethod")
+	copyField("url", "requestUrl")
+	copyField("full_url", "requestUrl")
+	copyField("response_status", "status")
+	copyField("User_Agent", "userAgent")
+	copyField(RemoteIPField, "remoteIp")
+	copyField("proto", "protocol")
+	copyField("content_length", "requestSize")
+	copyField("response_size", "responseSize")
+
+	duration, _ := fields.get(DurationField)
+	if duration, ok := duration.(int64); ok {
+		httpRequest["latency"] = fmt.Sprintf("%.3fs", float64(duration)/1000)
+	}
+
+	return httpRequest
+}
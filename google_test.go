@@ -0,0 +1,110 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GoogleSeverity(t *testing.T) {
+	assert.Equal(t, "DEBUG", googleSeverity(logrus.TraceLevel))
+	assert.Equal(t, "DEBUG", googleSeverity(logrus.DebugLevel))
+	assert.Equal(t, "INFO", googleSeverity(logrus.InfoLevel))
+	assert.Equal(t, "WARNING", googleSeverity(logrus.WarnLevel))
+	assert.Equal(t, "ERROR", googleSeverity(logrus.ErrorLevel))
+	assert.Equal(t, "CRITICAL", googleSeverity(logrus.PanicLevel))
+	assert.Equal(t, "EMERGENCY", googleSeverity(logrus.FatalLevel))
+}
+
+func Test_Google_TraceAndSourceLocation(t *testing.T) {
+	tp := initTracer()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{GoogleCloudLogging: true, GoogleProjectID: "my-project", Output: b})
+	require.NoError(t, err)
+
+	ctx, span := startSpan()
+	defer span.End()
+	logger.WithContext(ctx).Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "INFO", data["severity"])
+	assert.Equal(t, "projects/my-project/traces/"+span.SpanContext().TraceID().String(), data[googleTraceField])
+	assert.Equal(t, span.SpanContext().SpanID().String(), data[googleSpanIDField])
+	assert.Equal(t, true, data[googleTraceSampledField])
+
+	sourceLocation := data[googleSourceLocationField].(map[string]any)
+	assert.Equal(t, "google_test.go", sourceLocation["file"])
+	assert.Contains(t, sourceLocation["function"], "Test_Google_TraceAndSourceLocation")
+}
+
+func Test_Google_WithoutProjectIDTraceIsNotLinked(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "")
+	tp := initTracer()
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{GoogleCloudLogging: true, Output: b})
+	require.NoError(t, err)
+
+	ctx, span := startSpan()
+	defer span.End()
+	logger.WithContext(ctx).Info("message")
+
+	data := mapFromBuffer(b)
+	assert.NotContains(t, data, googleTraceField)
+	assert.Equal(t, span.SpanContext().TraceID().String(), data["traceID"])
+}
+
+func Test_Google_HTTPRequest(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{GoogleCloudLogging: true, Output: b})
+	require.NoError(t, err)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo?q=bar", nil)
+	r.Header.Set("User-Agent", "__agent__")
+	r.RemoteAddr = "127.0.0.1:1234"
+
+	logger.Access(r, time.Now().Add(-1500*time.Millisecond), http.StatusCreated)
+
+	data := mapFromBuffer(b)
+	httpRequest := data[googleHTTPRequestField].(map[string]any)
+	assert.Equal(t, "GET", httpRequest["requestMethod"])
+	assert.Equal(t, "/foo?q=bar", httpRequest["requestUrl"])
+	assert.Equal(t, 201.0, httpRequest["status"])
+	assert.Equal(t, "__agent__", httpRequest["userAgent"])
+	assert.Equal(t, "127.0.0.1", httpRequest["remoteIp"])
+	assert.Equal(t, "HTTP/1.1", httpRequest["protocol"])
+	assert.Regexp(t, `^1\.5\d\ds$`, httpRequest["latency"])
+
+	b.Reset()
+	logger.Call(r, &http.Response{StatusCode: http.StatusOK}, time.Now(), nil)
+
+	httpRequest = mapFromBuffer(b)[googleHTTPRequestField].(map[string]any)
+	assert.Equal(t, "http://www.example.org/foo?q=bar", httpRequest["requestUrl"])
+	assert.Equal(t, 200.0, httpRequest["status"])
+}
+
+func Test_Google_SourceLocationSkipsHelpers(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{GoogleCloudLogging: true, Output: b})
+	require.NoError(t, err)
+
+	logger.LifecycleStart("app", nil)
+	logger.Access(httptest.NewRequest(http.MethodGet, "/", nil), time.Now(), http.StatusOK)
+
+	data := mapsFromBuffer(b)
+	require.Len(t, data, 2)
+	sourceLocation := data[0][googleSourceLocationField].(map[string]any)
+	assert.Equal(t, "google_test.go", sourceLocation["file"])
+	assert.Contains(t, sourceLocation["function"], "Test_Google_SourceLocationSkipsHelpers")
+	assert.Nil(t, data[1][googleSourceLocationField])
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultMaskedHeaders are always masked, HeaderConfig.MaskedHeaders adds further headers.
+var DefaultMaskedHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"Api-Key",
+	"X-Auth-Token",
+}
+
+// HeaderConfig selects the request and response headers written to the access and call entries.
+// The headers are written as maps in the fields request_headers and response_headers, multiple
+// values of a header are joined with a comma.
+type HeaderConfig struct {
+	// RequestHeaders are the request headers written to the entries, case-insensitive.
+	RequestHeaders []string
+	// ResponseHeaders are the response headers written to the entries, case-insensitive.
+	ResponseHeaders []string
+	// MaskedHeaders are written with Mask instead of their values in addition to DefaultMaskedHeaders,
+	// case-insensitive. Headers containing "api-key" or "apikey" in their name are always masked.
+	MaskedHeaders []string
+	// Mask replaces the values of MaskedHeaders, defaults to "[REDACTED]".
+	Mask string
+}
+
+func (c *HeaderConfig) isMasked(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "api-key") || strings.Contains(lower, "apikey") {
+		return true
+	}
+
+	matches := func(m string) bool {
+		return strings.EqualFold(m, name)
+	}
+	return slices.ContainsFunc(DefaultMaskedHeaders, matches) || slices.ContainsFunc(c.MaskedHeaders, matches)
+}
+
+func (c *HeaderConfig) mask() string {
+	if c.Mask != "" {
+		return c.Mask
+	}
+	return defaultRedactionMask
+}
+
+// fields returns the selected headers of the request and the response, which may be nil.
+func (c *HeaderConfig) fields(request, response http.Header) logrus.Fields {
+	fields := logrus.Fields{}
+	if c == nil {
+		return fields
+	}
+
+	if headers := c.selected(c.RequestHeaders, request); len(headers) > 0 {
+		fields[RequestHeadersField] = headers
+	}
+	if headers := c.selected(c.ResponseHeaders, response); len(headers) > 0 {
+		fields[ResponseHeadersField] = headers
+	}
+	return fields
+}
+
+func (c *HeaderConfig) selected(names []string, header http.Header) map[string]string {
+	headers := map[string]string{}
+	for _, name := range names {
+		values := header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		name = http.CanonicalHeaderKey(name)
+		if c.isMasked(name) {
+			headers[name] = c.mask()
+		} else {
+			headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
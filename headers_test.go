@@ -0,0 +1,122 @@
+package logging
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_HeaderConfig_Fields(t *testing.T) {
+	config := &HeaderConfig{
+		RequestHeaders:  []string{"content-type", "Accept-Language", "Authorization", "X-Partner-Apikey", "X-Missing"},
+		ResponseHeaders: []string{"Content-Type", "Set-Cookie"},
+	}
+
+	request := http.Header{}
+	request.Set("Content-Type", "application/json")
+	request.Add("Accept-Language", "de")
+	request.Add("Accept-Language", "en")
+	request.Set("Authorization", "Bearer secret")
+	request.Set("X-Partner-Apikey", "secret")
+	request.Set("X-Client-Version", "1.2.3")
+
+	response := http.Header{}
+	response.Set("Content-Type", "text/plain")
+	response.Set("Set-Cookie", "session=secret")
+
+	assert.Equal(t, logrus.Fields{
+		RequestHeadersField: map[string]string{
+			"Content-Type":     "application/json",
+			"Accept-Language":  "de, en",
+			"Authorization":    "[REDACTED]",
+			"X-Partner-Apikey": "[REDACTED]",
+		},
+		ResponseHeadersField: map[string]string{
+			"Content-Type": "text/plain",
+			"Set-Cookie":   "[REDACTED]",
+		},
+	}, config.fields(request, response))
+}
+
+func Test_HeaderConfig_CustomMaskedHeaders(t *testing.T) {
+	config := &HeaderConfig{
+		RequestHeaders: []string{"Authorization", "X-Session", "X-Api-Key"},
+		MaskedHeaders:  []string{"x-session"},
+		Mask:           "***",
+	}
+
+	request := http.Header{}
+	request.Set("Authorization", "Basic abc")
+	request.Set("X-Session", "secret")
+	request.Set("X-Api-Key", "secret")
+
+	assert.Equal(t, logrus.Fields{
+		RequestHeadersField: map[string]string{
+			"Authorization": "***",
+			"X-Session":     "***",
+			"X-Api-Key":     "***",
+		},
+	}, config.fields(request, nil))
+}
+
+func Test_HeaderConfig_NotSet(t *testing.T) {
+	var config *HeaderConfig
+	assert.Empty(t, config.fields(http.Header{"Content-Type": {"text/plain"}}, nil))
+}
+
+func Test_LogMiddleware_Headers(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+	}), LogMiddlewareConfig{Logger: logger, Headers: &HeaderConfig{
+		RequestHeaders:  []string{"X-Client-Version", "Cookie"},
+		ResponseHeaders: []string{"Content-Type"},
+	}})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	r.Header.Set("X-Client-Version", "1.2.3")
+	r.Header.Set("Cookie", "session=secret")
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, map[string]any{"X-Client-Version": "1.2.3", "Cookie": "[REDACTED]"}, data[RequestHeadersField])
+	assert.Equal(t, map[string]any{"Content-Type": "application/json"}, data[ResponseHeadersField])
+}
+
+func Test_Call_Headers(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, CallHeaders: &HeaderConfig{
+		RequestHeaders:  []string{"Authorization"},
+		ResponseHeaders: []string{"Content-Language"},
+	}})
+	require.NoError(t, err)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{"Content-Language": {"de"}}}
+
+	logger.CallWarn(r, resp, time.Now(), nil)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "warning", data["level"])
+	assert.Equal(t, map[string]any{"Authorization": "[REDACTED]"}, data[RequestHeadersField])
+	assert.Equal(t, map[string]any{"Content-Language": "de"}, data[ResponseHeadersField])
+
+	b.Reset()
+	logger.Call(r, nil, time.Now(), nil)
+
+	data = mapFromBuffer(b)
+	assert.Contains(t, data, RequestHeadersField)
+	assert.NotContains(t, data, ResponseHeadersField)
+}
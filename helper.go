@@ -3,6 +3,8 @@ package logging
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"maps"
 	"net"
 	"net/http"
 	"os"
@@ -36,10 +38,44 @@ var DefaultLogConfig = LogConfig{
 }
 
 type LogConfig struct {
-	EnableTraces           bool
-	EnableTextLogging      bool
-	GoogleCloudLogging     bool
+	EnableTraces      bool
+	EnableTextLogging bool
+	// EnableLogfmtLogging writes the entries in logfmt, see LogfmtFormatter.
+	EnableLogfmtLogging bool
+	// EnableConsoleLogging writes colored entries for local development, see ConsoleFormatter.
+	// It is ignored if the output is not a terminal.
+	EnableConsoleLogging bool
+	GoogleCloudLogging   bool
+	// ElasticCommonSchema writes json with the field names of the Elastic Common Schema, see ECSFormatter.
+	ElasticCommonSchema    bool
 	LogLevelForServerError *logrus.Level
+	// GoogleProjectID links the entries to Cloud Trace if GoogleCloudLogging is enabled, defaults to the
+	// environment variable GOOGLE_CLOUD_PROJECT.
+	GoogleProjectID string
+	// Output is the writer the log entries are written to, defaults to os.Stderr if not set.
+	Output io.Writer
+	// OutputFile writes the entries to a rotated log file instead of Output, see FileWriter.
+	OutputFile *FileConfig
+	// ScopeLevels overrides the log level for entries with a matching ScopeField, see ParseScopeLevels.
+	// The most specific scope wins, so "payment.refund" takes precedence over "payment".
+	ScopeLevels map[string]logrus.Level
+	// Sampling limits the number of identical entries, no sampling is done if not set.
+	Sampling *SamplingConfig
+	// Async writes the entries in the background instead of blocking the logging goroutine, if set.
+	Async *AsyncConfig
+	// Redaction masks sensitive data in the message and fields, nothing is masked if not set.
+	Redaction *RedactionConfig
+	// Limits truncates large fields and entries, nothing is truncated if not set.
+	Limits *LogLimits
+	// Sinks routes the entries to multiple outputs, e.g. access entries to one output and errors to
+	// another. Output only sets the default output of the sinks, if set.
+	Sinks []Sink
+	// OTelLogs additionally emits the entries as otel log records, see tracex.NewOTLPLogProvider.
+	// The provider is not shut down by the Logger.
+	OTelLogs *tracex.LogProvider
+	// CallHeaders selects the request and response headers written to the entries of Call, CallWarn
+	// and FlakyCall, no headers are written if not set.
+	CallHeaders *HeaderConfig
 }
 
 func (c *LogConfig) getLogLevelForServerError() logrus.Level {
@@ -50,6 +86,13 @@ func (c *LogConfig) getLogLevelForServerError() logrus.Level {
 	return logrus.ErrorLevel
 }
 
+func (c *LogConfig) googleProjectID() string {
+	if c.GoogleProjectID != "" {
+		return c.GoogleProjectID
+	}
+	return os.Getenv("GOOGLE_CLOUD_PROJECT")
+}
+
 // Set creates a new Logger with the matching specification
 func Set(level string, textLogging bool) error {
 	config := &LogConfig{EnableTraces: true, EnableTextLogging: textLogging}
@@ -64,22 +107,65 @@ func SetGoogle(level string) error {
 }
 
 // SetWithConfig creates a new Logger with the matching specification based on the config, pass nil to use
-// the defaults.
+// the defaults. The previous global Log is closed, see Logger.Close.
 func SetWithConfig(level string, config *LogConfig) error {
+	logger, err := NewLogger(level, config)
+	if err != nil {
+		return err
+	}
+
+	previous := Log
+	Log = logger
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close the previous logger, %v\n", err)
+		}
+	}
+	return nil
+}
+
+// NewLogger creates a new Logger with the matching specification based on the config, pass nil to use
+// the defaults. In contrast to SetWithConfig the global Log is left untouched.
+func NewLogger(level string, config *LogConfig) (*Logger, error) {
 	if config == nil {
 		config = &DefaultLogConfig
 	}
 
 	l, err := logrus.ParseLevel(level)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if config.Sampling != nil {
+		if err := config.Sampling.validate(); err != nil {
+			return nil, err
+		}
 	}
 
 	logger := logrus.New()
-	if config.EnableTextLogging {
+	if config.Output != nil {
+		logger.Out = config.Output
+	}
+	var file *FileWriter
+	if config.OutputFile != nil {
+		file, err = NewFileWriter(*config.OutputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		logger.Out = file
+	}
+
+	reportCaller := false
+	if config.EnableConsoleLogging && isTerminal(logger.Out) {
+		logger.Formatter = &ConsoleFormatter{}
+	} else if config.EnableTextLogging {
 		logger.Formatter = &logrus.TextFormatter{DisableColors: true}
+	} else if config.EnableLogfmtLogging {
+		logger.Formatter = &LogfmtFormatter{TimestampFormat: time.RFC3339Nano}
 	} else if config.GoogleCloudLogging {
+		reportCaller = true
 		logger.Formatter = &LogstashFormatter{
+			GoogleCloudLogging: true,
+			GoogleProjectID:    config.googleProjectID(),
 			FieldMap: map[string]string{
 				// https://cloud.google.com/logging/docs/agent/logging/configuration#special-fields
 				"@timestamp":         "timestamp",
@@ -89,26 +175,80 @@ func SetWithConfig(level string, config *LogConfig) error {
 			},
 			TimestampFormat: time.RFC3339Nano,
 		}
+	} else if config.ElasticCommonSchema {
+		logger.Formatter = &ECSFormatter{TimestampFormat: time.RFC3339Nano}
 	} else {
 		logger.Formatter = &LogstashFormatter{TimestampFormat: time.RFC3339Nano}
 	}
 
+	newLogger := &Logger{Logger: logger, config: config, file: file}
+	if len(config.ScopeLevels) > 0 {
+		newLogger.scopes = newScopeLevels(l, config.ScopeLevels)
+	}
+	var sampler *sampler
+	if config.Sampling != nil {
+		sampler = newSampler(*config.Sampling)
+	}
+	// entries not enabled for their scope or suppressed by the sampling are dropped before the hooks fire
+	newLogger.filter = newEntryFilter(newLogger.scopes, sampler)
+	logger.AddHook(newLogger.filter)
+
+	newLogger.AddHook(&contextFieldsHook{})
+	if reportCaller {
+		newLogger.AddHook(&sourceLocationHook{})
+	}
+	if config.Redaction != nil {
+		newLogger.AddHook(&redactionHook{redactor: newRedactor(*config.Redaction)})
+	}
 	if config.EnableTraces {
-		logger.AddHook(tracex.NewLogrusHook())
+		newLogger.AddHook(tracex.NewLogrusHook())
+	}
+	if config.OTelLogs != nil {
+		newLogger.AddHook(config.OTelLogs.NewLogrusHook())
 	}
 
-	logger.Level = l
-	Log = &Logger{Logger: logger, config: config}
-	return nil
+	if config.Limits != nil {
+		logger.Formatter = &limitFormatter{Formatter: logger.Formatter, limits: *config.Limits}
+	}
+
+	var router *sinkRouter
+	if len(config.Sinks) > 0 {
+		router = newSinkRouter(config.Sinks, config, logger.Out, logger.Formatter)
+		logger.Formatter = router
+	}
+
+	if config.Async != nil {
+		newLogger.async = newAsyncWriter(logger.Out, *config.Async)
+		logger.Out = newLogger.async
+		if router != nil {
+			router.async = newLogger.async
+		} else {
+			logger.Formatter = &asyncFormatter{Formatter: logger.Formatter, writer: newLogger.async}
+		}
+	}
+
+	if newLogger.filter.filters() {
+		logger.Formatter = &filterFormatter{Formatter: logger.Formatter}
+	}
+
+	newLogger.SetLevel(l)
+	return newLogger, nil
 }
 
 // Access logs an access entry with call duration and status code
 func Access(r *http.Request, start time.Time, statusCode int) {
-	access(logrus.InfoLevel, r, start, statusCode)
+	Log.Access(r, start, statusCode)
+}
+
+// Access logs an access entry with call duration and status code
+func (l *Logger) Access(r *http.Request, start time.Time, statusCode int) {
+	l.access(logrus.InfoLevel, r, start, statusCode, nil, nil)
 }
 
-func access(level logrus.Level, r *http.Request, start time.Time, statusCode int) {
-	e := createAccessEntry(r, start, statusCode, nil)
+// access logs an access entry, metrics and the additional fields are added by the LogMiddleware
+// and may be nil.
+func (l *Logger) access(level logrus.Level, r *http.Request, start time.Time, statusCode int, metrics *responseMetrics, fields logrus.Fields) {
+	e := l.createAccessEntry(r, start, statusCode, nil, metrics).WithFields(fields)
 
 	var msg string
 	if len(r.URL.RawQuery) == 0 {
@@ -117,10 +257,14 @@ func access(level logrus.Level, r *http.Request, start time.Time, statusCode int
 		msg = fmt.Sprintf("%v ->%v %v?%s", statusCode, r.Method, r.URL.Path, r.URL.RawQuery)
 	}
 
-	e.Log(Log.accessLogLevelFor(level, r, statusCode), msg)
+	e.Log(l.accessLogLevelFor(level, r, statusCode), msg)
 }
 
 func (l *Logger) accessLogLevelFor(level logrus.Level, r *http.Request, statusCode int) logrus.Level {
+	if statusCode == http.StatusSwitchingProtocols {
+		// hijacked connections, e.g. websockets
+		return level
+	}
 	if statusCode < 200 {
 		// 100er codes are unexpected in the context of http handlers using this middleware
 		return logrus.ErrorLevel
@@ -143,7 +287,16 @@ func isHealthRequest(r *http.Request) bool {
 
 // AccessError logs an error while accessing
 func AccessError(r *http.Request, start time.Time, err error, stack []byte) {
-	e := createAccessEntry(r, start, 0, err)
+	Log.AccessError(r, start, err, stack)
+}
+
+// AccessError logs an error while accessing
+func (l *Logger) AccessError(r *http.Request, start time.Time, err error, stack []byte) {
+	l.accessError(r, start, err, stack, nil)
+}
+
+func (l *Logger) accessError(r *http.Request, start time.Time, err error, stack []byte, fields logrus.Fields) {
+	e := l.createAccessEntry(r, start, 0, err, nil).WithFields(fields)
 
 	if stack != nil {
 		e = e.WithField("stack", string(stack))
@@ -152,32 +305,53 @@ func AccessError(r *http.Request, start time.Time, err error, stack []byte) {
 	e.Errorf("ERROR ->%v %v", r.Method, r.URL.Path)
 }
 
+// AccessAborted logs an access entry for an aborted request
 func AccessAborted(r *http.Request, start time.Time) {
-	e := createAccessEntry(r, start, 0, nil)
+	Log.AccessAborted(r, start)
+}
+
+// AccessAborted logs an access entry for an aborted request
+func (l *Logger) AccessAborted(r *http.Request, start time.Time) {
+	l.accessAborted(r, start, nil)
+}
+
+func (l *Logger) accessAborted(r *http.Request, start time.Time, fields logrus.Fields) {
+	e := l.createAccessEntry(r, start, 0, nil, nil).WithFields(fields)
 	e.Infof("ABORTED ->%v %v", r.Method, r.URL.Path)
 }
 
-func createAccessEntry(r *http.Request, start time.Time, statusCode int, err error) *Entry {
+func (l *Logger) createAccessEntry(r *http.Request, start time.Time, statusCode int, err error, metrics *responseMetrics) *Entry {
 	url := r.URL.Path
 	if r.URL.RawQuery != "" {
 		url += "?" + r.URL.RawQuery
 	}
 	fields := logrus.Fields{
-		TypeField:    TypeAccess,
-		"@timestamp": start,
-		"remote_ip":  getRemoteIP(r),
-		"host":       r.Host,
-		"url":        url,
-		"method":     r.Method,
-		"proto":      r.Proto,
-		"duration":   time.Since(start).Nanoseconds() / 1000000,
-		"User_Agent": r.Header.Get("User-Agent"),
+		TypeField:     TypeAccess,
+		"@timestamp":  start,
+		RemoteIPField: getRemoteIP(r),
+		"host":        r.Host,
+		"url":         url,
+		"method":      r.Method,
+		"proto":       r.Proto,
+		"duration":    time.Since(start).Nanoseconds() / 1000000,
+		"User_Agent":  r.Header.Get("User-Agent"),
 	}
 
 	if statusCode != 0 {
 		fields["response_status"] = statusCode
 	}
 
+	if r.ContentLength > 0 {
+		fields["content_length"] = r.ContentLength
+	}
+
+	if metrics != nil {
+		fields["response_size"] = metrics.bytesWritten
+		if !metrics.firstByte.IsZero() {
+			fields["time_to_first_byte"] = metrics.firstByte.Sub(start).Nanoseconds() / 1000000
+		}
+	}
+
 	if err != nil {
 		fields[logrus.ErrorKey] = err.Error()
 	}
@@ -192,26 +366,41 @@ func createAccessEntry(r *http.Request, start time.Time, statusCode int, err err
 		fields["cookies"] = cookies
 	}
 
-	return Log.WithContext(r.Context()).WithFields(fields)
+	return l.entryFromContext(r.Context()).WithFields(fields)
 }
 
 // Call logs the result of an outgoing call. This logs on error level if the call failed, if that is not wanted use CallWarn instead.
 func Call(r *http.Request, resp *http.Response, start time.Time, err error) {
+	Log.Call(r, resp, start, err)
+}
+
+// Call logs the result of an outgoing call. This logs on error level if the call failed, if that is not wanted use CallWarn instead.
+func (l *Logger) Call(r *http.Request, resp *http.Response, start time.Time, err error) {
 	fields := fieldsForCall(r, resp, start, err)
-	logCall(fields, r, resp, err, logrus.ErrorLevel)
+	l.logCall(fields, r, resp, err, logrus.ErrorLevel)
 }
 
-// Call logs the result of an outgoing call. Same as Call but logs failed calls on warning level instead of error level.
+// CallWarn logs the result of an outgoing call. Same as Call but logs failed calls on warning level instead of error level.
 func CallWarn(r *http.Request, resp *http.Response, start time.Time, err error) {
+	Log.CallWarn(r, resp, start, err)
+}
+
+// CallWarn logs the result of an outgoing call. Same as Call but logs failed calls on warning level instead of error level.
+func (l *Logger) CallWarn(r *http.Request, resp *http.Response, start time.Time, err error) {
 	fields := fieldsForCall(r, resp, start, err)
-	logCall(fields, r, resp, err, logrus.WarnLevel)
+	l.logCall(fields, r, resp, err, logrus.WarnLevel)
 }
 
 // FlakyCall logs the result of an outgoing call and marks it as flaky
 func FlakyCall(r *http.Request, resp *http.Response, start time.Time, err error) {
+	Log.FlakyCall(r, resp, start, err)
+}
+
+// FlakyCall logs the result of an outgoing call and marks it as flaky
+func (l *Logger) FlakyCall(r *http.Request, resp *http.Response, start time.Time, err error) {
 	fields := fieldsForCall(r, resp, start, err)
 	fields[FlakyField] = true
-	logCall(fields, r, resp, err, logrus.ErrorLevel)
+	l.logCall(fields, r, resp, err, logrus.ErrorLevel)
 }
 
 func fieldsForCall(r *http.Request, resp *http.Response, start time.Time, err error) logrus.Fields {
@@ -242,8 +431,14 @@ func fieldsForCall(r *http.Request, resp *http.Response, start time.Time, err er
 	return fields
 }
 
-func logCall(fields logrus.Fields, r *http.Request, resp *http.Response, err error, levelForErrors logrus.Level) {
-	entry := Log.WithContext(r.Context()).WithFields(fields)
+func (l *Logger) logCall(fields logrus.Fields, r *http.Request, resp *http.Response, err error, levelForErrors logrus.Level) {
+	var respHeader http.Header
+	if resp != nil {
+		respHeader = resp.Header
+	}
+	maps.Copy(fields, l.config.CallHeaders.fields(r.Header, respHeader))
+
+	entry := l.entryFromContext(r.Context()).WithFields(fields)
 
 	if ctxErr := r.Context().Err(); ctxErr != nil {
 		entry.Info(fmt.Sprintf("Context canceled for %s-> %s with error: %s", r.Method, r.URL.String(), ctxErr.Error()))
@@ -273,13 +468,18 @@ func logCall(fields logrus.Fields, r *http.Request, resp *http.Response, err err
 
 // Cacheinfo logs the hit information an accessing a resource
 func Cacheinfo(url string, hit bool) {
+	Log.Cacheinfo(url, hit)
+}
+
+// Cacheinfo logs the hit information an accessing a resource
+func (l *Logger) Cacheinfo(url string, hit bool) {
 	var msg string
 	if hit {
 		msg = fmt.Sprintf("cache hit: %v", url)
 	} else {
 		msg = fmt.Sprintf("cache miss: %v", url)
 	}
-	Log.WithFields(
+	l.WithFields(
 		logrus.Fields{
 			TypeField: TypeCacheinfo,
 			"url":     url,
@@ -290,15 +490,26 @@ func Cacheinfo(url string, hit bool) {
 
 // Application Return a log entry for application logs.
 func Application(h http.Header) *Entry {
+	return Log.Application(h)
+}
+
+// Application Return a log entry for application logs.
+func (l *Logger) Application(h http.Header) *Entry {
 	fields := logrus.Fields{
 		TypeField: TypeApplication,
 	}
-	return Log.WithFields(fields)
+	return l.WithFields(fields)
 }
 
 // LifecycleStart logs the start of an application
 // with the configuration struct or map as parameter.
 func LifecycleStart(appName string, args any) {
+	Log.LifecycleStart(appName, args)
+}
+
+// LifecycleStart logs the start of an application
+// with the configuration struct or map as parameter.
+func (l *Logger) LifecycleStart(appName string, args any) {
 	fields := logrus.Fields{}
 
 	if args != nil {
@@ -319,11 +530,16 @@ func LifecycleStart(appName string, args any) {
 		}
 	}
 
-	Log.WithFields(fields).Infof("starting application: %v", appName)
+	l.WithFields(fields).Infof("starting application: %v", appName)
 }
 
 // LifecycleStop logs the request to stop an application
 func LifecycleStop(appName string, signal os.Signal, err error) {
+	Log.LifecycleStop(appName, signal, err)
+}
+
+// LifecycleStop logs the request to stop an application
+func (l *Logger) LifecycleStop(appName string, signal os.Signal, err error) {
 	fields := logrus.Fields{
 		TypeField: TypeLifecycle,
 		"event":   "stop",
@@ -337,26 +553,33 @@ func LifecycleStop(appName string, signal os.Signal, err error) {
 	}
 
 	if err != nil {
-		Log.WithFields(fields).
+		l.WithFields(fields).
 			WithError(err).
 			Errorf("stopping application: %v (%v)", appName, err)
 	} else {
-		Log.WithFields(fields).Infof("stopping application: %v (%v)", appName, signal)
+		l.WithFields(fields).Infof("stopping application: %v (%v)", appName, signal)
 	}
+
+	l.flushOnStop()
 }
 
 // LifecycleStoped logs the stop of an application
 // Deprecated: Typo in name LifecycleStoped, please use LifecycleStopped instead.
 func LifecycleStoped(appName string, err error) {
-	logApplicationLifecycleEvent(appName, "stoped", err)
+	Log.logApplicationLifecycleEvent(appName, "stoped", err)
 }
 
 // LifecycleStopped logs the stop of an application
 func LifecycleStopped(appName string, err error) {
-	logApplicationLifecycleEvent(appName, "stopped", err)
+	Log.LifecycleStopped(appName, err)
+}
+
+// LifecycleStopped logs the stop of an application
+func (l *Logger) LifecycleStopped(appName string, err error) {
+	l.logApplicationLifecycleEvent(appName, "stopped", err)
 }
 
-func logApplicationLifecycleEvent(appName string, eventName string, err error) {
+func (l *Logger) logApplicationLifecycleEvent(appName string, eventName string, err error) {
 	fields := logrus.Fields{
 		TypeField: TypeLifecycle,
 		"event":   eventName,
@@ -367,16 +590,23 @@ func logApplicationLifecycleEvent(appName string, eventName string, err error) {
 	}
 
 	if err != nil {
-		Log.WithFields(fields).
+		l.WithFields(fields).
 			WithError(err).
 			Errorf("stopping application: %v (%v)", appName, err)
 	} else {
-		Log.WithFields(fields).Infof("application %s: %v", eventName, appName)
+		l.WithFields(fields).Infof("application %s: %v", eventName, appName)
 	}
+
+	l.flushOnStop()
 }
 
 // ServerClosed logs the closing of a server
 func ServerClosed(appName string) {
+	Log.ServerClosed(appName)
+}
+
+// ServerClosed logs the closing of a server
+func (l *Logger) ServerClosed(appName string) {
 	fields := logrus.Fields{
 		TypeField: TypeApplication,
 		"event":   "stop",
@@ -386,7 +616,7 @@ func ServerClosed(appName string) {
 		fields["build_number"] = os.Getenv("BUILD_NUMBER")
 	}
 
-	Log.WithFields(fields).Infof("http server was closed: %v", appName)
+	l.WithFields(fields).Infof("http server was closed: %v", appName)
 }
 
 func getRemoteIP(r *http.Request) string {
@@ -48,22 +48,22 @@ func Test_SetGoogle(t *testing.T) {
 		logFn   func()
 	}{
 		{
-			level:   "debug",
+			level:   "DEBUG",
 			message: "__debug__",
 			logFn:   func() { Log.Debug("__debug__") },
 		},
 		{
-			level:   "info",
+			level:   "INFO",
 			message: "__info__",
 			logFn:   func() { Log.Info("__info__") },
 		},
 		{
-			level:   "warning",
+			level:   "WARNING",
 			message: "__warn__",
 			logFn:   func() { Log.Warn("__warn__") },
 		},
 		{
-			level:   "error",
+			level:   "ERROR",
 			message: "__error__",
 			logFn:   func() { Log.Error("__error__") },
 		},
@@ -76,7 +76,7 @@ func Test_SetGoogle(t *testing.T) {
 
 			tc.logFn()
 
-			result := map[string]string{}
+			result := map[string]any{}
 			require.NoError(t, json.Unmarshal(b.Bytes(), &result))
 
 			assert.Equal(t, tc.message, result["message"])
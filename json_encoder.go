@@ -0,0 +1,538 @@
+package logging
+
+import (
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// jsonFieldsPool reuses the field slices and buffers of the LogstashFormatter.
+var jsonFieldsPool = sync.Pool{
+	New: func() any {
+		return &jsonFields{fields: make([]jsonField, 0, 32)}
+	},
+}
+
+// maxPooledJSONBuffer prevents single huge entries from keeping their buffers in the pool.
+const maxPooledJSONBuffer = 64 << 10
+
+type jsonFieldKind uint8
+
+const (
+	jsonFieldValue jsonFieldKind = iota
+	jsonFieldString
+	jsonFieldText
+)
+
+// jsonField holds the value of one field. Strings and rendered text are stored without boxing them into
+// an interface, so they do not allocate.
+type jsonField struct {
+	key   string
+	kind  jsonFieldKind
+	value any
+	str   string
+	text  []byte
+}
+
+func (f jsonField) get() any {
+	switch f.kind {
+	case jsonFieldString:
+		return f.str
+	case jsonFieldText:
+		return string(f.text)
+	}
+	return f.value
+}
+
+// jsonFields collects the fields of an entry and encodes them as a json object with sorted keys, which
+// produces the same output as json.Marshal of a map.
+type jsonFields struct {
+	fields []jsonField
+	// scratch holds the rendered text of the fields, earlier fields keep referencing their part of it
+	// even if it grows.
+	scratch []byte
+	out     []byte
+}
+
+func getJSONFields() *jsonFields {
+	return jsonFieldsPool.Get().(*jsonFields)
+}
+
+func putJSONFields(f *jsonFields) {
+	if cap(f.scratch) > maxPooledJSONBuffer || cap(f.out) > maxPooledJSONBuffer {
+		return
+	}
+	clear(f.fields)
+	f.fields = f.fields[:0]
+	f.scratch = f.scratch[:0]
+	f.out = f.out[:0]
+	jsonFieldsPool.Put(f)
+}
+
+func (f *jsonFields) index(key string) int {
+	for i := range f.fields {
+		if f.fields[i].key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *jsonFields) get(key string) (any, bool) {
+	if i := f.index(key); i >= 0 {
+		return f.fields[i].get(), true
+	}
+	return nil, false
+}
+
+func (f *jsonFields) put(field jsonField) {
+	if i := f.index(field.key); i >= 0 {
+		f.fields[i] = field
+		return
+	}
+	f.fields = append(f.fields, field)
+}
+
+// add adds a field without looking for an existing field with the same key.
+func (f *jsonFields) add(field jsonField) {
+	f.fields = append(f.fields, field)
+}
+
+func (f *jsonFields) set(key string, value any) {
+	f.put(jsonField{key: key, value: value})
+}
+
+func (f *jsonFields) setString(key, value string) {
+	f.put(jsonField{key: key, kind: jsonFieldString, str: value})
+}
+
+func (f *jsonFields) setTime(key string, t time.Time, layout string) {
+	start := len(f.scratch)
+	f.scratch = t.AppendFormat(f.scratch, layout)
+	f.put(jsonField{key: key, kind: jsonFieldText, text: f.scratch[start:len(f.scratch):len(f.scratch)]})
+}
+
+func (f *jsonFields) setHex(key string, value []byte) {
+	start := len(f.scratch)
+	f.scratch = hex.AppendEncode(f.scratch, value)
+	f.put(jsonField{key: key, kind: jsonFieldText, text: f.scratch[start:len(f.scratch):len(f.scratch)]})
+}
+
+// rename moves the value of the field name to replacement, overwriting an existing replacement.
+func (f *jsonFields) rename(name, replacement string) {
+	i := f.index(name)
+	if i < 0 || name == replacement {
+		return
+	}
+	if j := f.index(replacement); j >= 0 {
+		f.fields = slices.Delete(f.fields, j, j+1)
+		if j < i {
+			i--
+		}
+	}
+	f.fields[i].key = replacement
+}
+
+// appendJSON appends the fields as json object to dst.
+func (f *jsonFields) appendJSON(dst []byte) []byte {
+	slices.SortFunc(f.fields, func(a, b jsonField) int {
+		return strings.Compare(a.key, b.key)
+	})
+
+	dst = append(dst, '{')
+	for i := range f.fields {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendJSONString(dst, f.fields[i].key)
+		dst = append(dst, ':')
+
+		switch f.fields[i].kind {
+		case jsonFieldString:
+			dst = appendJSONString(dst, f.fields[i].str)
+		case jsonFieldText:
+			dst = appendJSONString(dst, f.fields[i].text)
+		default:
+			dst = appendJSONValue(dst, f.fields[i].value)
+		}
+	}
+	return append(dst, '}')
+}
+
+// appendJSONValue appends the value like json.Marshal does. The common types are written directly,
+// everything else is passed to json.Marshal. Values which cannot be encoded are replaced by a marker,
+// so a single field never causes the loss of the entry.
+func appendJSONValue(dst []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case string:
+		return appendJSONString(dst, v)
+	case bool:
+		return strconv.AppendBool(dst, v)
+	case int:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(dst, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(dst, v, 10)
+	case uint:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(dst, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(dst, v, 10)
+	case float32:
+		if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+			return appendJSONFloat(dst, float64(v), 32)
+		}
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return appendJSONFloat(dst, v, 64)
+		}
+	}
+
+	if safe, changed := jsonSafeValue(reflect.ValueOf(value)); changed {
+		value = safe
+	}
+
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return appendJSONString(dst, unencodable(value, fmt.Sprintf("%v", value)))
+	}
+	return append(dst, serialized...)
+}
+
+// appendJSONFloat formats floats like encoding/json.
+func appendJSONFloat(dst []byte, f float64, bits int) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString quotes s like encoding/json, including the escaping of HTML characters.
+func appendJSONString[T string | []byte](dst []byte, s T) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '\\', '"':
+				dst = append(dst, '\\', c)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRune([]byte(s[i:min(i+utf8.UTFMax, len(s))]))
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\uFFFD"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// UnencodableMarker prefixes the values which cannot be encoded as json, e.g. channels, functions, NaN or
+// cyclic structures. It is followed by the type and the %v representation of the value.
+const UnencodableMarker = "!UNENCODABLE"
+
+func unencodable(value any, representation string) string {
+	return fmt.Sprintf("%s(%T): %s", UnencodableMarker, value, representation)
+}
+
+var (
+	errorType         = reflect.TypeFor[error]()
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// valueSanitizer replaces the parts of field values which cannot be encoded or exceed the limits.
+// Errors are replaced by their message, because json.Marshal ignores their unexported fields, channels,
+// functions, NaN and cycles by a marker. Maps, slices and structs containing such values are copied into
+// generic maps and slices, the caller's values are not modified.
+type valueSanitizer struct {
+	// maxStringLength truncates longer strings, if > 0.
+	maxStringLength int
+	// maxDepth replaces maps, slices and structs nested deeper than this, if > 0.
+	maxDepth int
+}
+
+// jsonSafeValue returns a replacement for v, if v contains errors or values which cannot be encoded.
+// The returned bool is false if v can be encoded as it is.
+func jsonSafeValue(v reflect.Value) (any, bool) {
+	return valueSanitizer{}.sanitize(v, 0, nil)
+}
+
+// sanitize returns a replacement for v and true, if v needs to be changed. depth is the number of maps,
+// slices and structs containing v, path the pointers of the containers to detect cycles.
+func (s valueSanitizer) sanitize(v reflect.Value, depth int, path []uintptr) (any, bool) {
+	if !v.IsValid() {
+		return nil, false
+	}
+
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, false
+		}
+		return s.sanitize(v.Elem(), depth, path)
+	}
+
+	if v.Type().Implements(errorType) && !(v.Kind() == reflect.Pointer && v.IsNil()) {
+		return s.truncateString(v.Interface().(error).Error()), true
+	}
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return nil, false
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if s.maxStringLength > 0 && v.Len() > s.maxStringLength {
+			return s.truncateString(v.String()), true
+		}
+
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			return nil, false
+		}
+		return unencodable(v.Interface(), fmt.Sprintf("%v", v.Interface())), true
+
+	case reflect.Complex64, reflect.Complex128:
+		return unencodable(v.Interface(), fmt.Sprintf("%v", v.Interface())), true
+
+	case reflect.Float32, reflect.Float64:
+		if f := v.Float(); math.IsNaN(f) || math.IsInf(f, 0) {
+			return unencodable(v.Interface(), fmt.Sprintf("%v", f)), true
+		}
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, false
+		}
+		if slices.Contains(path, v.Pointer()) {
+			return unencodable(v.Interface(), fmt.Sprintf("cycle at %p", v.Interface())), true
+		}
+		return s.sanitize(v.Elem(), depth, append(path, v.Pointer()))
+
+	case reflect.Map:
+		if v.IsNil() || v.Len() == 0 {
+			return nil, false
+		}
+		if slices.Contains(path, v.Pointer()) {
+			return unencodable(v.Interface(), fmt.Sprintf("cycle at %p", v.Interface())), true
+		}
+		if s.exceedsDepth(depth) {
+			return truncatedDepth(v), true
+		}
+		path = append(path, v.Pointer())
+
+		var result map[string]any
+		for iter := v.MapRange(); iter.Next(); {
+			if _, changed := s.sanitize(iter.Value(), depth+1, path); changed {
+				result = make(map[string]any, v.Len())
+				break
+			}
+		}
+		if result == nil {
+			return nil, false
+		}
+		for iter := v.MapRange(); iter.Next(); {
+			key := fmt.Sprint(iter.Key().Interface())
+			if safe, changed := s.sanitize(iter.Value(), depth+1, path); changed {
+				result[key] = safe
+			} else {
+				result[key] = iter.Value().Interface()
+			}
+		}
+		return result, true
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && (v.IsNil() || v.Type().Elem().Kind() == reflect.Uint8) {
+			return nil, false
+		}
+		if v.Len() == 0 {
+			return nil, false
+		}
+		if v.Kind() == reflect.Slice {
+			if slices.Contains(path, v.Pointer()) {
+				return unencodable(v.Interface(), fmt.Sprintf("cycle at %p", v.Interface())), true
+			}
+			path = append(path, v.Pointer())
+		}
+		if s.exceedsDepth(depth) {
+			return truncatedDepth(v), true
+		}
+
+		var result []any
+		for i := range v.Len() {
+			safe, changed := s.sanitize(v.Index(i), depth+1, path)
+			if changed && result == nil {
+				result = make([]any, v.Len())
+				for j := range i {
+					result[j] = v.Index(j).Interface()
+				}
+			}
+			if changed {
+				result[i] = safe
+			} else if result != nil {
+				result[i] = v.Index(i).Interface()
+			}
+		}
+		if result == nil {
+			return nil, false
+		}
+		return result, true
+
+	case reflect.Struct:
+		if s.exceedsDepth(depth) {
+			return truncatedDepth(v), true
+		}
+
+		changed := false
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				if _, changed = s.sanitize(v.Field(i), depth+1, path); changed {
+					break
+				}
+			}
+		}
+		if !changed {
+			return nil, false
+		}
+
+		result := map[string]any{}
+		s.addStructFields(result, v, depth, path)
+		return result, true
+	}
+
+	return nil, false
+}
+
+func (s valueSanitizer) exceedsDepth(depth int) bool {
+	return s.maxDepth > 0 && depth >= s.maxDepth
+}
+
+func (s valueSanitizer) truncateString(value string) string {
+	if s.maxStringLength <= 0 || len(value) <= s.maxStringLength {
+		return value
+	}
+	return truncated(value, s.maxStringLength)
+}
+
+// addStructFields adds the exported fields of the struct to result, following the names and options of
+// the json tags.
+func (s valueSanitizer) addStructFields(result map[string]any, v reflect.Value, depth int, path []uintptr) {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		value := v.Field(i)
+		if field.Anonymous && name == "" {
+			if value.Kind() == reflect.Pointer {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				s.addStructFields(result, value, depth, path)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if strings.Contains(options, "omitempty") && isEmptyJSONValue(value) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if safe, changed := s.sanitize(value, depth+1, path); changed {
+			result[name] = safe
+		} else {
+			result[name] = value.Interface()
+		}
+	}
+}
+
+// isEmptyJSONValue reports whether v is omitted by the omitempty option of encoding/json.
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type node struct {
+	Name string
+	Next *node
+}
+
+type request struct {
+	Path    string `json:"path"`
+	Err     error  `json:"err,omitempty"`
+	Ignored string `json:"-"`
+	Empty   string `json:"empty,omitempty"`
+	secret  string
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("failed")
+}
+
+func Test_LogstashFormatter_ReplacesUnencodableFields(t *testing.T) {
+	cyclic := &node{Name: "a"}
+	cyclic.Next = &node{Name: "b", Next: cyclic}
+
+	cyclicMap := map[string]any{"name": "m"}
+	cyclicMap["self"] = cyclicMap
+
+	entry := logrus.WithFields(logrus.Fields{
+		"channel":   make(chan int),
+		"func":      map[string]any{"callback": func() {}},
+		"cyclic":    cyclic,
+		"cyclicMap": cyclicMap,
+		"marshaler": failingMarshaler{},
+		"nested":    map[string]any{"err": errors.New("nested error"), "values": []any{1, errors.New("in slice")}},
+		"struct":    request{Path: "/path", Err: errors.New("struct error"), Ignored: "x", secret: "y"},
+		"plain":     map[string]any{"a": 1},
+	})
+	entry.Message = "still logged"
+
+	b, err := (&LogstashFormatter{}).Format(entry)
+	require.NoError(t, err)
+	data := mapFromBuffer(bytes.NewBuffer(b))
+
+	assert.Equal(t, "still logged", data["message"])
+	assert.Regexp(t, `^!UNENCODABLE\(chan int\): 0x[0-9a-f]+$`, data["channel"])
+	assert.Regexp(t, `^!UNENCODABLE\(func\(\)\): 0x[0-9a-f]+$`, data["func"].(map[string]any)["callback"])
+	assert.Equal(t, "a", data["cyclic"].(map[string]any)["Name"])
+	assert.Regexp(t, `^!UNENCODABLE\(\*logging.node\): cycle at 0x[0-9a-f]+$`, data["cyclic"].(map[string]any)["Next"].(map[string]any)["Next"])
+	assert.Equal(t, "m", data["cyclicMap"].(map[string]any)["name"])
+	assert.Regexp(t, `^!UNENCODABLE\(map\[string\]interface \{\}\): cycle at 0x[0-9a-f]+$`, data["cyclicMap"].(map[string]any)["self"])
+	assert.Equal(t, "!UNENCODABLE(logging.failingMarshaler): {}", data["marshaler"])
+	assert.Equal(t, map[string]any{"err": "nested error", "values": []any{1.0, "in slice"}}, data["nested"])
+	assert.Equal(t, map[string]any{"path": "/path", "err": "struct error"}, data["struct"])
+	assert.Equal(t, map[string]any{"a": 1.0}, data["plain"])
+}
+
+func Test_ECSFormatter_ReplacesUnencodableFields(t *testing.T) {
+	entry := logrus.WithFields(logrus.Fields{"channel": make(chan int), "nested": map[string]any{"err": errors.New("nested error")}})
+	entry.Message = "still logged"
+
+	b, err := (&ECSFormatter{}).Format(entry)
+	require.NoError(t, err)
+	data := mapFromBuffer(bytes.NewBuffer(b))
+
+	assert.Equal(t, "still logged", data["message"])
+	assert.Regexp(t, `^!UNENCODABLE\(chan int\): 0x[0-9a-f]+$`, data["channel"])
+	assert.Equal(t, map[string]any{"err": "nested error"}, data["nested"])
+}
@@ -0,0 +1,182 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LevelHandler is an http.Handler to inspect and change the level of a Logger at runtime.
+//
+// GET returns the current state. PUT and POST change the level with a JSON body like
+// {"level": "debug", "duration": "10m"}. If a duration is given, the level is reverted after it elapsed.
+type LevelHandler struct {
+	logger   *Logger
+	mu       sync.Mutex
+	base     logrus.Level
+	override *levelOverride
+}
+
+type levelOverride struct {
+	until time.Time
+	timer *time.Timer
+}
+
+type levelRequest struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration,omitempty"`
+}
+
+type levelResponse struct {
+	Level         string     `json:"level"`
+	DefaultLevel  string     `json:"default_level"`
+	OverrideUntil *time.Time `json:"override_until,omitempty"`
+}
+
+// NewLevelHandler creates a LevelHandler for the global Logger.
+func NewLevelHandler() *LevelHandler {
+	return &LevelHandler{}
+}
+
+// NewLevelHandlerForLogger creates a LevelHandler for the given Logger.
+func NewLevelHandlerForLogger(logger *Logger) *LevelHandler {
+	return &LevelHandler{logger: logger}
+}
+
+func (h *LevelHandler) getLogger() *Logger {
+	if h.logger != nil {
+		return h.logger
+	}
+	return Log
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeState(w)
+	case http.MethodPut, http.MethodPost:
+		req := levelRequest{}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		level, err := logrus.ParseLevel(req.Level)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var duration time.Duration
+		if req.Duration != "" {
+			duration, err = time.ParseDuration(req.Duration)
+			if err != nil || duration <= 0 {
+				http.Error(w, fmt.Sprintf("invalid duration: '%s'", req.Duration), http.StatusBadRequest)
+				return
+			}
+		}
+
+		h.SetLevel(level, duration)
+		h.writeState(w)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// SetLevel changes the level of the Logger. A positive duration makes the change temporary,
+// the previous level is restored after it elapsed.
+func (h *LevelHandler) SetLevel(level logrus.Level, duration time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	logger := h.getLogger()
+	current := logger.GetLevel()
+	if h.override == nil {
+		h.base = current
+	}
+	h.stopOverride()
+
+	fields := logrus.Fields{
+		TypeField:    TypeLifecycle,
+		"event":      "log_level_changed",
+		"old_level":  current.String(),
+		"new_level":  level.String(),
+		"persistent": duration <= 0,
+	}
+
+	if duration > 0 {
+		fields["override_duration"] = duration.String()
+		override := &levelOverride{until: time.Now().Add(duration)}
+		override.timer = time.AfterFunc(duration, func() { h.revert(override) })
+		h.override = override
+	} else {
+		h.base = level
+	}
+
+	changeLevel(logger, current, level, fields, fmt.Sprintf("log level changed from %s to %s", current, level))
+}
+
+func (h *LevelHandler) revert(override *levelOverride) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// the override may have been replaced while the timer was firing
+	if h.override != override {
+		return
+	}
+	h.override = nil
+
+	logger := h.getLogger()
+	current := logger.GetLevel()
+	fields := logrus.Fields{
+		TypeField:   TypeLifecycle,
+		"event":     "log_level_reverted",
+		"old_level": current.String(),
+		"new_level": h.base.String(),
+	}
+	changeLevel(logger, current, h.base, fields, fmt.Sprintf("log level override expired, reverted from %s to %s", current, h.base))
+}
+
+func (h *LevelHandler) stopOverride() {
+	if h.override != nil {
+		h.override.timer.Stop()
+		h.override = nil
+	}
+}
+
+// changeLevel sets the level and logs the change while the more verbose of both levels is active,
+// so the change is always visible. It is logged at Info, or at the more verbose level if both
+// levels are above Info, e.g. warn to error.
+func changeLevel(logger *Logger, from, to logrus.Level, fields logrus.Fields, msg string) {
+	level := min(logrus.InfoLevel, max(from, to))
+	if to > from {
+		logger.SetLevel(to)
+		logger.WithFields(fields).Log(level, msg)
+		return
+	}
+
+	logger.WithFields(fields).Log(level, msg)
+	logger.SetLevel(to)
+}
+
+func (h *LevelHandler) writeState(w http.ResponseWriter) {
+	h.mu.Lock()
+	level := h.getLogger().GetLevel().String()
+	resp := levelResponse{
+		Level:        level,
+		DefaultLevel: level,
+	}
+	if h.override != nil {
+		resp.DefaultLevel = h.base.String()
+		resp.OverrideUntil = &h.override.until
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
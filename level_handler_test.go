@@ -0,0 +1,120 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LevelHandler_Get(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("warn", false))
+
+	resp := httptest.NewRecorder()
+	NewLevelHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/loglevel", nil))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	data := map[string]any{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &data))
+	assert.Equal(t, "warning", data["level"])
+	assert.Equal(t, "warning", data["default_level"])
+	assert.Nil(t, data["override_until"])
+}
+
+func Test_LevelHandler_Put_ChangesLevelAndLogsLifecycle(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("info", false))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	resp := httptest.NewRecorder()
+	body := strings.NewReader(`{"level": "debug"}`)
+	NewLevelHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/loglevel", body))
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, logrus.DebugLevel, Log.GetLevel())
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "lifecycle", data["type"])
+	assert.Equal(t, "log_level_changed", data["event"])
+	assert.Equal(t, "info", data["old_level"])
+	assert.Equal(t, "debug", data["new_level"])
+}
+
+func Test_LevelHandler_Put_LogsChangesAboveInfo(t *testing.T) {
+	defer Set("info", true)
+
+	for _, levels := range [][2]string{{"warning", "error"}, {"error", "warning"}} {
+		require.NoError(t, Set(levels[0], false))
+		b := bytes.NewBuffer(nil)
+		Log.Out = b
+
+		resp := httptest.NewRecorder()
+		body := strings.NewReader(`{"level": "` + levels[1] + `"}`)
+		NewLevelHandler().ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/loglevel", body))
+		require.Equal(t, http.StatusOK, resp.Code)
+
+		data := mapFromBuffer(b)
+		assert.Equal(t, "warning", data["level"])
+		assert.Equal(t, "log_level_changed", data["event"])
+		assert.Equal(t, levels[0], data["old_level"])
+		assert.Equal(t, levels[1], data["new_level"])
+	}
+}
+
+func Test_LevelHandler_Post_TemporaryOverrideReverts(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, Set("warn", false))
+	Log.Out = bytes.NewBuffer(nil)
+
+	handler := NewLevelHandler()
+	resp := httptest.NewRecorder()
+	body := strings.NewReader(`{"level": "debug", "duration": "50ms"}`)
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/loglevel", body))
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	data := map[string]any{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &data))
+	assert.Equal(t, "debug", data["level"])
+	assert.Equal(t, "warning", data["default_level"])
+	assert.NotEmpty(t, data["override_until"])
+
+	assert.Eventually(t, func() bool {
+		return Log.GetLevel() == logrus.WarnLevel
+	}, time.Second, 10*time.Millisecond)
+}
+
+func Test_LevelHandler_InvalidRequests(t *testing.T) {
+	defer Set("info", true)
+
+	for _, test := range []struct {
+		name   string
+		method string
+		body   string
+		code   int
+	}{
+		{"invalid json", http.MethodPut, `{`, http.StatusBadRequest},
+		{"invalid level", http.MethodPut, `{"level": "loud"}`, http.StatusBadRequest},
+		{"invalid duration", http.MethodPut, `{"level": "debug", "duration": "soon"}`, http.StatusBadRequest},
+		{"negative duration", http.MethodPut, `{"level": "debug", "duration": "-1m"}`, http.StatusBadRequest},
+		{"unsupported method", http.MethodDelete, ``, http.StatusMethodNotAllowed},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			require.NoError(t, Set("info", false))
+
+			resp := httptest.NewRecorder()
+			NewLevelHandler().ServeHTTP(resp, httptest.NewRequest(test.method, "/loglevel", strings.NewReader(test.body)))
+
+			assert.Equal(t, test.code, resp.Code)
+			assert.Equal(t, logrus.InfoLevel, Log.GetLevel())
+		})
+	}
+}
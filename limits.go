@@ -0,0 +1,153 @@
+package logging
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TruncatedMarker is appended to truncated values, followed by the original size.
+const TruncatedMarker = "!TRUNCATED"
+
+// LogLimits truncates entries which exceed the limits of the log pipeline, e.g. 256KB of Google Cloud
+// Logging or 32KB of Logstash. Truncated values are marked with TruncatedMarker and their original size.
+type LogLimits struct {
+	// MaxFieldLength truncates the message and string values longer than this number of bytes.
+	MaxFieldLength int
+	// MaxEntrySize truncates the largest fields until the formatted entry fits into this number of bytes.
+	MaxEntrySize int
+	// MaxDepth replaces maps, slices and structs nested deeper than this in the field values.
+	// A map of strings has a depth of 1.
+	MaxDepth int
+}
+
+// truncated cuts value to at most maxLength bytes without splitting runes and appends the marker.
+func truncated(value string, maxLength int) string {
+	cut := max(maxLength, 0)
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return withTruncatedMarker(value[:cut], len(value))
+}
+
+// withTruncatedMarker appends the marker to the kept prefix of a value of size bytes.
+func withTruncatedMarker(prefix string, size int) string {
+	return fmt.Sprintf("%s...%s(%d bytes)", prefix, TruncatedMarker, size)
+}
+
+// truncatedDepth is the replacement of a map, slice or struct exceeding LogLimits.MaxDepth.
+func truncatedDepth(v reflect.Value) string {
+	if v.Kind() == reflect.Struct {
+		return fmt.Sprintf("%s(max depth, %s)", TruncatedMarker, v.Type())
+	}
+	return fmt.Sprintf("%s(max depth, %s with %d elements)", TruncatedMarker, v.Type(), v.Len())
+}
+
+// truncatedSize is the replacement of a field removed to meet LogLimits.MaxEntrySize.
+func truncatedSize(size int) string {
+	return fmt.Sprintf("%s(%d bytes)", TruncatedMarker, size)
+}
+
+// limitFormatter applies the LogLimits to the entries before they are formatted.
+type limitFormatter struct {
+	logrus.Formatter
+	limits LogLimits
+}
+
+func (f *limitFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	sanitizer := valueSanitizer{maxStringLength: f.limits.MaxFieldLength, maxDepth: f.limits.MaxDepth}
+
+	limited := *entry
+	limited.Message = sanitizer.truncateString(entry.Message)
+	if sanitizer != (valueSanitizer{}) {
+		limited.Data = make(logrus.Fields, len(entry.Data))
+		for k, v := range entry.Data {
+			if safe, changed := sanitizer.sanitize(reflect.ValueOf(v), 0, nil); changed {
+				v = safe
+			}
+			limited.Data[k] = v
+		}
+	}
+
+	serialized, err := f.Formatter.Format(&limited)
+	if err != nil || f.limits.MaxEntrySize <= 0 || len(serialized) <= f.limits.MaxEntrySize {
+		return serialized, err
+	}
+
+	if sanitizer == (valueSanitizer{}) {
+		// the fields are modified below, so they must not be shared with the entry
+		limited.Data = maps.Clone(entry.Data)
+	}
+	return f.formatWithinSize(&limited, len(serialized))
+}
+
+// formatWithinSize truncates the largest fields until the entry fits into LogLimits.MaxEntrySize.
+// If it still does not fit after truncating all fields, the entry is written anyway.
+func (f *limitFormatter) formatWithinSize(entry *logrus.Entry, size int) ([]byte, error) {
+	done := map[string]bool{}
+	messageDone := false
+	for {
+		key, fieldSize, isMessage := f.largestField(entry, done, messageDone)
+		if key == "" && !isMessage {
+			break
+		}
+
+		// leave some room for the marker
+		keep := fieldSize - (size - f.limits.MaxEntrySize) - len(TruncatedMarker) - 20
+		if isMessage {
+			messageDone = true
+			entry.Message = truncated(entry.Message, keep)
+		} else if s, ok := entry.Data[key].(string); ok && keep > 0 {
+			done[key] = true
+			entry.Data[key] = truncated(s, keep)
+		} else {
+			done[key] = true
+			entry.Data[key] = truncatedSize(fieldSize)
+		}
+
+		if entry.Buffer != nil {
+			entry.Buffer.Reset()
+		}
+		serialized, err := f.Formatter.Format(entry)
+		if err != nil || len(serialized) <= f.limits.MaxEntrySize {
+			return serialized, err
+		}
+		size = len(serialized)
+	}
+
+	if entry.Buffer != nil {
+		entry.Buffer.Reset()
+	}
+	return f.Formatter.Format(entry)
+}
+
+// largestField returns the key of the largest field which is not yet truncated and its encoded size.
+// isMessage is true, if the message is larger than all fields.
+func (f *limitFormatter) largestField(entry *logrus.Entry, done map[string]bool, messageDone bool) (key string, size int, isMessage bool) {
+	if !messageDone && entry.Message != "" {
+		size, isMessage = len(entry.Message), true
+	}
+
+	var buf []byte
+	for _, k := range slices.Sorted(maps.Keys(entry.Data)) {
+		if done[k] {
+			continue
+		}
+
+		fieldSize := 0
+		if s, ok := entry.Data[k].(string); ok {
+			fieldSize = len(s)
+		} else {
+			buf = appendJSONValue(buf[:0], entry.Data[k])
+			fieldSize = len(buf)
+		}
+		if fieldSize > size {
+			key, size, isMessage = k, fieldSize, false
+		}
+	}
+	return key, size, isMessage
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Limits_MaxFieldLength(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, Limits: &LogLimits{MaxFieldLength: 10}})
+	require.NoError(t, err)
+
+	payload := map[string]any{"body": strings.Repeat("x", 100)}
+	logger.WithFields(logrus.Fields{
+		PayloadField: payload,
+		"short":      "short",
+		"unicode":    "ääääääää",
+	}).Info(strings.Repeat("m", 20))
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "mmmmmmmmmm...!TRUNCATED(20 bytes)", data["message"])
+	assert.Equal(t, map[string]any{"body": "xxxxxxxxxx...!TRUNCATED(100 bytes)"}, data[PayloadField])
+	assert.Equal(t, "short", data["short"])
+	assert.Equal(t, "äääää...!TRUNCATED(16 bytes)", data["unicode"])
+
+	// values owned by the caller are not modified
+	assert.Len(t, payload["body"], 100)
+}
+
+func Test_Limits_MaxDepth(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, Limits: &LogLimits{MaxDepth: 2}})
+	require.NoError(t, err)
+
+	logger.WithFields(logrus.Fields{
+		"nested": map[string]any{
+			"a": map[string]any{"b": map[string]any{"c": 1}},
+			"s": []int{1, 2, 3},
+		},
+		"flat": map[string]string{"a": "b"},
+	}).Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, map[string]any{
+		"a": map[string]any{"b": "!TRUNCATED(max depth, map[string]interface {} with 1 elements)"},
+		"s": []any{1.0, 2.0, 3.0},
+	}, data["nested"])
+	assert.Equal(t, map[string]any{"a": "b"}, data["flat"])
+}
+
+func Test_Limits_MaxEntrySize(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, Limits: &LogLimits{MaxEntrySize: 1024}})
+	require.NoError(t, err)
+
+	logger.WithFields(logrus.Fields{
+		"stacktrace":  strings.Repeat("s", 2000),
+		ResponseField: map[string]any{"items": strings.Split(strings.Repeat("item,", 500), ",")},
+		"small":       "value",
+	}).Info("message")
+
+	line := b.String()
+	assert.LessOrEqual(t, len(line), 1024)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "message", data["message"])
+	assert.Equal(t, "value", data["small"])
+	assert.Regexp(t, `^!TRUNCATED\(\d+ bytes\)$`, data[ResponseField])
+	assert.Regexp(t, `^s+\.\.\.!TRUNCATED\(2000 bytes\)$`, data["stacktrace"])
+}
+
+func Test_Limits_MaxEntrySize_WritesEntryWhichCannotBeTruncated(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, Limits: &LogLimits{MaxEntrySize: 10}})
+	require.NoError(t, err)
+
+	logger.WithField("a", 1).Info("message")
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, "...!TRUNCATED(7 bytes)", data["message"])
+	assert.Equal(t, "!TRUNCATED(1 bytes)", data["a"])
+}
+
+func Test_Limits_TextFormatter(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, EnableTextLogging: true, Limits: &LogLimits{MaxFieldLength: 5}})
+	require.NoError(t, err)
+
+	logger.WithField("stacktrace", "0123456789").Info("message")
+
+	assert.Contains(t, b.String(), `stacktrace="01234...!TRUNCATED(10 bytes)"`)
+}
@@ -1,8 +1,14 @@
 package logging
 
 import (
+	"bufio"
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net"
 	"net/http"
 	"regexp"
 	"runtime"
@@ -10,6 +16,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/felixge/httpsnoop"
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"github.com/snabble/go-logging/v2/tracex"
 )
@@ -19,12 +27,46 @@ type LogMiddlewareConfig struct {
 	// expressions, the access log is skipped if for request where the
 	// request path matches the expression.
 	SkipSuccessfulRequestsMatching []string
+
+	// Logger is used for the access logs, defaults to the global Log.
+	Logger *Logger
+
+	// RequestIDHeader is the header carrying the request id, defaults to DefaultRequestIDHeader.
+	// The id of the request is taken from it or generated if missing, echoed in the response and
+	// added to the access log and the entries bound to the context of the request.
+	RequestIDHeader string
+
+	// DisableRequestID disables the handling of request ids.
+	DisableRequestID bool
+
+	// Headers selects the request and response headers written to the access log, no headers are
+	// written if not set. Credentials like the Authorization header are masked, see HeaderConfig.
+	Headers *HeaderConfig
+
+	// CaptureBodies writes the request and response bodies of failed requests to the access log,
+	// nothing is captured if not set. The bodies are buffered up to a size limit, see BodyCaptureConfig.
+	CaptureBodies *BodyCaptureConfig
+
+	// ClientIP resolves the client ip of the access log behind trusted proxies, see ClientIPConfig.
+	// If not set, the headers X-Cluster-Client-Ip and X-Real-Ip are used without any checks.
+	ClientIP *ClientIPConfig
 }
 
+// DefaultRequestIDHeader is the default of LogMiddlewareConfig.RequestIDHeader.
+const DefaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength limits the length of inbound request ids, longer ids are replaced.
+const maxRequestIDLength = 128
+
 type LogMiddleware struct {
 	Next http.Handler
 
-	skipCache []*regexp.Regexp
+	logger          *Logger
+	skipCache       []*regexp.Regexp
+	requestIDHeader string
+	headers         *HeaderConfig
+	captureBodies   *BodyCaptureConfig
+	clientIP        *clientIPResolver
 }
 
 func NewLogMiddleware(next http.Handler) http.Handler {
@@ -38,12 +80,26 @@ func AddLogMiddleware(next http.Handler, cfg LogMiddlewareConfig) (http.Handler,
 		return nil, err
 	}
 
+	clientIP, err := newClientIPResolver(cfg.ClientIP)
+	if err != nil {
+		return nil, err
+	}
+
 	middleware := &LogMiddleware{
 		Next:      next,
+		logger:    cfg.Logger,
 		skipCache: skipCache,
+		headers:   cfg.Headers,
+
+		captureBodies: cfg.CaptureBodies,
+		clientIP:      clientIP,
+	}
+
+	if !cfg.DisableRequestID {
+		middleware.requestIDHeader = cmp.Or(cfg.RequestIDHeader, DefaultRequestIDHeader)
 	}
 
-	if Log.config.EnableTraces {
+	if middleware.getLogger().config.EnableTraces {
 		return tracex.NewHandler(middleware, "common"), nil
 	}
 
@@ -65,31 +121,73 @@ func buildSkipCache(cfg LogMiddlewareConfig) ([]*regexp.Regexp, error) {
 	return skipCache, nil
 }
 
+func (mw *LogMiddleware) getLogger() *Logger {
+	if mw.logger != nil {
+		return mw.logger
+	}
+	return Log
+}
+
 func (mw *LogMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
+	logger := mw.getLogger()
 
-	lrw := &logResponseWriter{ResponseWriter: w}
+	if mw.requestIDHeader != "" {
+		requestID := requestIDFrom(r.Header.Get(mw.requestIDHeader))
+		w.Header().Set(mw.requestIDHeader, requestID)
+
+		ctx := ContextWithRequestID(r.Context(), requestID)
+		ctx = context.WithValue(ctx, requestIDHeaderContextKey{}, mw.requestIDHeader)
+		if existing, ok := ctx.Value(entryContextKey{}).(*Entry); ok {
+			ctx = ContextWithEntry(ctx, existing.WithField(RequestIDField, requestID))
+		}
+		r = r.WithContext(ctx)
+	}
+
+	if _, ok := r.Context().Value(entryContextKey{}).(*Entry); !ok {
+		r = r.WithContext(ContextWithEntry(r.Context(), logger.WithContext(r.Context())))
+	}
+
+	metrics := &responseMetrics{}
+	capture := newBodyCapture(mw.captureBodies)
+	if capture != nil {
+		r = capture.wrapRequest(r)
+		metrics.capture = capture
+	}
+	rw := metrics.wrap(w)
 
 	defer func() {
 		if rec := recover(); rec != nil {
-			lrw.WriteHeader(http.StatusInternalServerError)
+			rw.WriteHeader(http.StatusInternalServerError)
 			// See: https://pkg.go.dev/net/http#ErrAbortHandler
 			if recErr, ok := rec.(error); ok && errors.Is(recErr, http.ErrAbortHandler) {
-				AccessAborted(r, start)
+				logger.accessAborted(r, start, mw.accessFields(r, rw, metrics.statusCode, capture))
 				return
 			}
-			AccessError(r, start, fmt.Errorf("PANIC (%v): %v", identifyLogOrigin(), rec), debug.Stack())
+			logger.accessError(r, start, fmt.Errorf("PANIC (%v): %v", identifyLogOrigin(), rec), debug.Stack(),
+				mw.accessFields(r, rw, metrics.statusCode, capture))
 		}
 	}()
 
-	mw.Next.ServeHTTP(lrw, r)
+	mw.Next.ServeHTTP(rw, r)
 
 	level := logrus.InfoLevel
 	if mw.isSkipped(r.URL.Path) {
 		level = logrus.DebugLevel
 	}
 
-	access(level, r, start, lrw.statusCode)
+	logger.access(level, r, start, metrics.statusCode, metrics, mw.accessFields(r, rw, metrics.statusCode, capture))
+}
+
+// accessFields returns the headers, the captured bodies and the resolved client ip written to the
+// access log.
+func (mw *LogMiddleware) accessFields(r *http.Request, w http.ResponseWriter, statusCode int, capture *bodyCapture) logrus.Fields {
+	fields := mw.headers.fields(r.Header, w.Header())
+	maps.Copy(fields, capture.fields(r, w, statusCode))
+	if mw.clientIP != nil {
+		fields[RemoteIPField] = mw.clientIP.resolve(r)
+	}
+	return fields
 }
 
 func (mw *LogMiddleware) isSkipped(path string) bool {
@@ -101,6 +199,43 @@ func (mw *LogMiddleware) isSkipped(path string) bool {
 	return false
 }
 
+// requestIDFrom returns the inbound request id if it is valid, otherwise a new random id.
+// Valid ids consist of up to maxRequestIDLength printable ASCII characters.
+func requestIDFrom(inbound string) string {
+	if inbound == "" || len(inbound) > maxRequestIDLength {
+		return uuid.NewString()
+	}
+	for i := 0; i < len(inbound); i++ {
+		if inbound[i] <= ' ' || inbound[i] > '~' {
+			return uuid.NewString()
+		}
+	}
+	return inbound
+}
+
+type requestIDHeaderContextKey struct{}
+
+// ForwardRequestID sets the request id stored in the context of the outgoing request r, e.g. by the
+// LogMiddleware of the incoming request, in the header configured for the LogMiddleware.
+// The header is left unchanged if the context has no request id.
+//
+//	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+//	logging.ForwardRequestID(r)
+//	resp, err := client.Do(r)
+//	logging.Call(r, resp, start, err)
+func ForwardRequestID(r *http.Request) {
+	requestID := RequestIDFromContext(r.Context())
+	if requestID == "" {
+		return
+	}
+
+	header, ok := r.Context().Value(requestIDHeaderContextKey{}).(string)
+	if !ok {
+		header = DefaultRequestIDHeader
+	}
+	r.Header.Set(header, requestID)
+}
+
 // identifyLogOrigin returns the location, where a panic was raised
 // in the form package/subpackage.method:line
 func identifyLogOrigin() string {
@@ -131,19 +266,78 @@ func identifyLogOrigin() string {
 	return fmt.Sprintf("pc:%x", pc)
 }
 
-type logResponseWriter struct {
-	http.ResponseWriter
-	statusCode int
+// responseMetrics records the status code, the size and the time of the first byte of a response.
+type responseMetrics struct {
+	statusCode   int
+	bytesWritten int64
+	firstByte    time.Time
+	// capture captures the response body, if set
+	capture *bodyCapture
 }
 
-func (lrw *logResponseWriter) Write(b []byte) (int, error) {
-	if lrw.statusCode == 0 {
-		lrw.statusCode = 200
+// wrap returns a ResponseWriter recording the metrics, which implements the same optional interfaces
+// as w, e.g. http.Flusher for server-sent events and http.Hijacker for websockets.
+func (m *responseMetrics) wrap(w http.ResponseWriter) http.ResponseWriter {
+	return httpsnoop.Wrap(w, httpsnoop.Hooks{
+		WriteHeader: func(next httpsnoop.WriteHeaderFunc) httpsnoop.WriteHeaderFunc {
+			return func(statusCode int) {
+				m.statusCode = statusCode
+				m.markFirstByte()
+				next(statusCode)
+			}
+		},
+		Write: func(next httpsnoop.WriteFunc) httpsnoop.WriteFunc {
+			return func(b []byte) (int, error) {
+				m.markWritten()
+				n, err := next(b)
+				m.bytesWritten += int64(n)
+				if m.capture.capturesResponse(m.statusCode) {
+					_, _ = m.capture.response.Write(b[:n])
+				}
+				return n, err
+			}
+		},
+		ReadFrom: func(next httpsnoop.ReadFromFunc) httpsnoop.ReadFromFunc {
+			return func(src io.Reader) (int64, error) {
+				m.markWritten()
+				// the tee prevents sendfile, so it is only used if the body is logged
+				if m.capture.capturesResponse(m.statusCode) {
+					src = io.TeeReader(src, m.capture.response)
+				}
+				n, err := next(src)
+				m.bytesWritten += n
+				return n, err
+			}
+		},
+		Hijack: func(next httpsnoop.HijackFunc) httpsnoop.HijackFunc {
+			return func() (net.Conn, *bufio.ReadWriter, error) {
+				conn, rw, err := next()
+				if err == nil && m.statusCode == 0 {
+					// the handler writes the response to the connection, e.g. a websocket upgrade
+					m.statusCode = http.StatusSwitchingProtocols
+				}
+				return conn, rw, err
+			}
+		},
+		Flush: func(next httpsnoop.FlushFunc) httpsnoop.FlushFunc {
+			return func() {
+				m.markWritten()
+				next()
+			}
+		},
+	})
+}
+
+// markWritten records the implicit status 200 of responses written without WriteHeader.
+func (m *responseMetrics) markWritten() {
+	if m.statusCode == 0 {
+		m.statusCode = http.StatusOK
 	}
-	return lrw.ResponseWriter.Write(b)
+	m.markFirstByte()
 }
 
-func (lrw *logResponseWriter) WriteHeader(statusCode int) {
-	lrw.statusCode = statusCode
-	lrw.ResponseWriter.WriteHeader(statusCode)
+func (m *responseMetrics) markFirstByte() {
+	if m.firstByte.IsZero() {
+		m.firstByte = time.Now()
+	}
 }
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -240,3 +243,209 @@ func initTracer() *sdktrace.TracerProvider {
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
 	return tp
 }
+
+func Test_LogMiddleware_UsesConfiguredLogger(t *testing.T) {
+	globalBuffer := bytes.NewBuffer(nil)
+	Log.Out = globalBuffer
+
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), LogMiddlewareConfig{Logger: logger})
+	require.NoError(t, err)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Zero(t, globalBuffer.Len())
+	assert.Equal(t, "204 ->GET /foo", logRecordFromBuffer(b).Message)
+}
+
+func Test_LogMiddleware_RecordsSizesAndTimeToFirstByte(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		_, _ = w.Write([]byte("hello "))
+		_, _ = w.Write([]byte("world"))
+	}), LogMiddlewareConfig{Logger: logger})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodPost, "http://www.example.org/foo", strings.NewReader(`{"a":1}`))
+	lm.ServeHTTP(httptest.NewRecorder(), r)
+
+	data := mapFromBuffer(b)
+	assert.Equal(t, 200.0, data["response_status"])
+	assert.Equal(t, 11.0, data["response_size"])
+	assert.Equal(t, 7.0, data["content_length"])
+	assert.Contains(t, data, "time_to_first_byte")
+}
+
+func Test_LogMiddleware_ForwardsOptionalInterfaces(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	var flusher, hijacker, readerFrom bool
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, flusher = w.(http.Flusher)
+		_, hijacker = w.(http.Hijacker)
+		_, readerFrom = w.(io.ReaderFrom)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: first\n\n"))
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		_, _ = w.(io.ReaderFrom).ReadFrom(strings.NewReader("data: second\n\n"))
+	}), LogMiddlewareConfig{Logger: logger})
+	require.NoError(t, err)
+
+	// the access entry is written after the response is sent
+	served := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lm.ServeHTTP(w, r)
+		close(served)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, "data: first\n\ndata: second\n\n", string(body))
+	assert.True(t, flusher)
+	assert.True(t, hijacker)
+	assert.True(t, readerFrom)
+
+	<-served
+	data := mapFromBuffer(b)
+	assert.Equal(t, 200.0, data["response_status"])
+	assert.Equal(t, 27.0, data["response_size"])
+}
+
+func Test_LogMiddleware_Hijack(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: test\r\n\r\n")
+		_ = buf.Flush()
+	}), LogMiddlewareConfig{Logger: logger})
+	require.NoError(t, err)
+
+	// the access entry is written after the connection is handed back
+	served := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lm.ServeHTTP(w, r)
+		close(served)
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "test")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	<-served
+	data := mapFromBuffer(b)
+	assert.Equal(t, "info", data["level"])
+	assert.Equal(t, 101.0, data["response_status"])
+	assert.Equal(t, "101 ->GET /ws", data["message"])
+}
+
+func Test_LogMiddleware_RequestID(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	var outgoing *http.Request
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		EntryFromContext(r.Context()).Info("from handler")
+
+		outgoing, _ = http.NewRequestWithContext(r.Context(), http.MethodGet, "http://backend.example.org/bar", nil)
+		ForwardRequestID(outgoing)
+		logger.Call(outgoing, &http.Response{StatusCode: http.StatusOK}, time.Now(), nil)
+	}), LogMiddlewareConfig{Logger: logger})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	r.Header.Set("X-Request-Id", "__request_id__")
+	w := httptest.NewRecorder()
+	lm.ServeHTTP(w, r)
+
+	assert.Equal(t, "__request_id__", w.Header().Get("X-Request-Id"))
+	assert.Equal(t, "__request_id__", outgoing.Header.Get("X-Request-Id"))
+
+	records := logRecordsFromBuffer(b)
+	require.Len(t, records, 3)
+	for _, record := range records {
+		assert.Equal(t, "__request_id__", record.RequestID)
+	}
+	assert.Equal(t, "from handler", records[0].Message)
+	assert.Equal(t, TypeCall, records[1].Type)
+	assert.Equal(t, TypeAccess, records[2].Type)
+}
+
+func Test_LogMiddleware_RequestID_Generated(t *testing.T) {
+	for name, inbound := range map[string]string{
+		"missing":  "",
+		"invalid":  "foo\tbar",
+		"too long": strings.Repeat("x", maxRequestIDLength+1),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b := bytes.NewBuffer(nil)
+			logger, err := NewLogger("info", &LogConfig{Output: b})
+			require.NoError(t, err)
+
+			lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}), LogMiddlewareConfig{Logger: logger, RequestIDHeader: "X-Correlation-Id"})
+			require.NoError(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+			r.Header.Set("X-Correlation-Id", inbound)
+			w := httptest.NewRecorder()
+			lm.ServeHTTP(w, r)
+
+			requestID := w.Header().Get("X-Correlation-Id")
+			assert.Len(t, requestID, 36)
+			assert.Empty(t, w.Header().Get("X-Request-Id"))
+			assert.Equal(t, requestID, logRecordFromBuffer(b).RequestID)
+		})
+	}
+}
+
+func Test_LogMiddleware_RequestID_Disabled(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b})
+	require.NoError(t, err)
+
+	lm, err := AddLogMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), LogMiddlewareConfig{Logger: logger, DisableRequestID: true})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	r.Header.Set("X-Request-Id", "__request_id__")
+	w := httptest.NewRecorder()
+	lm.ServeHTTP(w, r)
+
+	assert.Empty(t, w.Header().Get("X-Request-Id"))
+	assert.NotContains(t, mapFromBuffer(b), RequestIDField)
+}
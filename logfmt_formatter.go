@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"github.com/snabble/go-logging/v2/tracex"
+)
+
+// LogfmtFormatter generates logfmt, see https://brandur.org/logfmt.
+//
+// Every entry is written as exactly one line starting with time, level and msg followed by the fields
+// sorted by key. Nested maps are flattened with dotted keys, e.g. cookies.session=abc, values containing
+// spaces, quotes or control characters are quoted and escaped, so multi-line stacktraces stay on one line.
+type LogfmtFormatter struct {
+	// TimestampFormat sets the format used for timestamps, defaults to time.RFC3339Nano.
+	TimestampFormat string
+}
+
+func (f *LogfmtFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	fields := make(logrus.Fields, len(entry.Data)+2)
+	for k, v := range entry.Data {
+		switch k {
+		case "time", "level", "msg":
+			fields["fields."+k] = v
+		default:
+			fields[k] = v
+		}
+	}
+	tracex.TraceAndSpan(entry.Context, fields)
+
+	timeStampFormat := f.TimestampFormat
+	if timeStampFormat == "" {
+		timeStampFormat = time.RFC3339Nano
+	}
+
+	b := entry.Buffer
+	if b == nil {
+		b = &bytes.Buffer{}
+	}
+
+	writeLogfmtPair(b, "time", entry.Time.Format(timeStampFormat))
+	writeLogfmtPair(b, "level", entry.Level.String())
+	writeLogfmtPair(b, "msg", entry.Message)
+
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		writeLogfmtValue(b, k, fields[k])
+	}
+
+	b.WriteByte('\n')
+	return b.Bytes(), nil
+}
+
+func writeLogfmtValue(b *bytes.Buffer, key string, value any) {
+	switch v := value.(type) {
+	case nil:
+		writeLogfmtPair(b, key, "")
+	case string:
+		writeLogfmtPair(b, key, v)
+	case error:
+		writeLogfmtPair(b, key, v.Error())
+	case time.Time:
+		writeLogfmtPair(b, key, v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		writeLogfmtPair(b, key, v.String())
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		writeLogfmtPair(b, key, fmt.Sprint(v))
+	default:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+			if rv.Len() == 0 {
+				writeLogfmtPair(b, key, "")
+				return
+			}
+
+			nested := make(map[string]any, rv.Len())
+			for iter := rv.MapRange(); iter.Next(); {
+				nested[iter.Key().String()] = iter.Value().Interface()
+			}
+			for _, k := range slices.Sorted(maps.Keys(nested)) {
+				writeLogfmtValue(b, key+"."+k, nested[k])
+			}
+			return
+		}
+
+		// slices and structs are rendered as json, which is stable and can be parsed again
+		serialized, err := json.Marshal(value)
+		if err != nil {
+			writeLogfmtPair(b, key, fmt.Sprintf("%+v", value))
+			return
+		}
+		writeLogfmtPair(b, key, string(serialized))
+	}
+}
+
+func writeLogfmtPair(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(logfmtKey(key))
+	b.WriteByte('=')
+
+	if needsLogfmtQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// logfmtKey replaces the characters which are not allowed in keys.
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+func needsLogfmtQuoting(value string) bool {
+	if value == "" {
+		return true
+	}
+	for _, r := range value {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
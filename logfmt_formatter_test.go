@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LogfmtFormatter(t *testing.T) {
+	f := &LogfmtFormatter{}
+
+	entry := logrus.WithFields(logrus.Fields{
+		"count":      3,
+		"cookies":    map[string]string{"b": "2", "a": "one two"},
+		"error":      errors.New(`said "no"`),
+		"stacktrace": "line 1\nline 2",
+		"tags":       []string{"x", "y"},
+		"empty":      "",
+		"msg":        "shadowed",
+		"odd key":    "v",
+	})
+	entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry.Level = logrus.WarnLevel
+	entry.Message = "something happened"
+
+	b, err := f.Format(entry)
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		`time=2024-01-02T03:04:05Z level=warning msg="something happened" `+
+			`cookies.a="one two" cookies.b=2 count=3 empty="" error="said \"no\"" fields.msg=shadowed `+
+			`odd_key=v stacktrace="line 1\nline 2" tags="[\"x\",\"y\"]"`+"\n",
+		string(b))
+}
+
+func Test_LogfmtFormatter_OneLinePerEntry(t *testing.T) {
+	f := &LogfmtFormatter{}
+
+	entry := logrus.WithField("payload", map[string]any{"nested": map[string]any{"text": "a\r\nb\tc"}})
+	entry.Message = "multi\nline"
+
+	b, err := f.Format(entry)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, strings.Count(string(b), "\n"))
+	assert.Contains(t, string(b), `payload.nested.text="a\r\nb\tc"`)
+	assert.Contains(t, string(b), `msg="multi\nline"`)
+}
+
+func Test_SetWithConfig_Logfmt(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, SetWithConfig("info", &LogConfig{EnableLogfmtLogging: true}))
+
+	assert.IsType(t, &LogfmtFormatter{}, Log.Formatter)
+}
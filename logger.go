@@ -2,6 +2,7 @@ package logging
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"time"
@@ -17,6 +18,8 @@ const (
 	OrderField          = "order"
 	TransactionField    = "transaction"
 	CheckoutDeviceField = "checkoutDevice"
+	RequestIDField      = "request_id"
+	RemoteIPField       = "remote_ip"
 
 	DurationField = "duration"
 	FlakyField    = "flaky"
@@ -24,6 +27,9 @@ const (
 	PayloadField  = "payload"
 	CountField    = "count"
 
+	RequestHeadersField  = "request_headers"
+	ResponseHeadersField = "response_headers"
+
 	ScopeField = "scope"
 	TopicField = "topic"
 
@@ -45,6 +51,102 @@ var Log *Logger
 type Logger struct {
 	*logrus.Logger
 	config *LogConfig
+	scopes *scopeLevels
+	async  *asyncWriter
+	// file is the output opened for LogConfig.OutputFile
+	file *FileWriter
+	// filter holds the hooks and fires them for the entries, which are not filtered by scope or sampling
+	filter *entryFilter
+}
+
+// SetLevel sets the level of the logger. Scopes configured in LogConfig.ScopeLevels keep their own level.
+func (logger *Logger) SetLevel(level logrus.Level) {
+	if logger.scopes == nil {
+		logger.Logger.SetLevel(level)
+		return
+	}
+
+	logger.scopes.setBaseLevel(level)
+	logger.Logger.SetLevel(logger.scopes.threshold())
+}
+
+// GetLevel returns the level of the logger, not taking the LogConfig.ScopeLevels into account.
+func (logger *Logger) GetLevel() logrus.Level {
+	if logger.scopes == nil {
+		return logger.Logger.GetLevel()
+	}
+
+	return logger.scopes.baseLevel()
+}
+
+// IsLevelEnabled checks whether the level of the logger is greater than the given level, not taking
+// the LogConfig.ScopeLevels into account, see IsScopeLevelEnabled.
+func (logger *Logger) IsLevelEnabled(level logrus.Level) bool {
+	return logger.GetLevel() >= level
+}
+
+// IsScopeLevelEnabled checks whether entries with the level are logged for the scope, taking the
+// LogConfig.ScopeLevels into account.
+func (logger *Logger) IsScopeLevelEnabled(scope string, level logrus.Level) bool {
+	if logger.scopes == nil {
+		return logger.IsLevelEnabled(level)
+	}
+	return logger.scopes.levelFor(scope) >= level
+}
+
+// SetFormatter sets the formatter of the logger. If entries are filtered by the LogConfig.ScopeLevels
+// or the LogConfig.Sampling, the formatter is wrapped to drop the filtered entries. It replaces the
+// formatters of other options like LogConfig.Async or LogConfig.Sinks.
+func (logger *Logger) SetFormatter(formatter logrus.Formatter) {
+	if logger.filter.filters() {
+		formatter = &filterFormatter{Formatter: formatter}
+	}
+	logger.Logger.SetFormatter(formatter)
+}
+
+// AddHook adds a hook to the logger. If entries are filtered by the LogConfig.ScopeLevels or the
+// LogConfig.Sampling, the hook only fires for the entries which are logged.
+func (logger *Logger) AddHook(hook logrus.Hook) {
+	if logger.filter == nil {
+		logger.Logger.AddHook(hook)
+		return
+	}
+	logger.filter.add(hook)
+}
+
+// RemoveHook removes a hook added with AddHook from all levels, e.g. when a test finishes. The hook
+// is compared with ==, so it must be comparable, which pointers are.
+func (logger *Logger) RemoveHook(hook logrus.Hook) {
+	if logger.filter != nil {
+		logger.filter.remove(hook)
+		return
+	}
+
+	// a Logger not created by NewLogger has no entryFilter, the hooks are missing shortly while replaced
+	hooks := logger.ReplaceHooks(logrus.LevelHooks{})
+	logger.ReplaceHooks(withoutHook(hooks, hook))
+}
+
+// Close closes the global Log, see Logger.Close.
+func Close() error {
+	return Log.Close()
+}
+
+// Close releases the resources owned by the logger. The queued entries of LogConfig.Async are written,
+// bounded by AsyncConfig.FlushTimeout, and its background goroutine is stopped, so later entries are
+// written synchronously. The log file opened for LogConfig.OutputFile is closed including its handling
+// of SIGHUP, entries logged to the closed file are lost.
+func (logger *Logger) Close() error {
+	var errs []error
+	if logger.async != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), logger.async.config.flushTimeout())
+		defer cancel()
+		errs = append(errs, logger.async.Close(ctx))
+	}
+	if logger.file != nil {
+		errs = append(errs, logger.file.Close())
+	}
+	return errors.Join(errs...)
 }
 
 func (logger *Logger) WithError(err error) *Entry {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"strings"
@@ -33,6 +34,7 @@ type logRecord struct {
 	SpanID         string            `json:"span"`
 	TraceID        string            `json:"trace"`
 	Stack          string            `json:"stack"`
+	RequestID      string            `json:"request_id"`
 }
 
 func Test_Logger_Set(t *testing.T) {
@@ -417,3 +419,52 @@ func mapFromBuffer(b *bytes.Buffer) map[string]any {
 	}
 	return data
 }
+
+func Test_NewLogger_LeavesGlobalLogUntouched(t *testing.T) {
+	a := assert.New(t)
+	global := Log
+
+	// given: an isolated logger
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("debug", &LogConfig{Output: b})
+	a.NoError(err)
+
+	// when: helpers are used on it
+	r, _ := http.NewRequest("GET", "http://www.example.org/foo", nil)
+	logger.Access(r, time.Now(), 200)
+	logger.Cacheinfo("/foo", true)
+
+	// then: the global logger is not replaced and the entries are written to the isolated logger
+	a.Same(global, Log)
+	records := logRecordsFromBuffer(b)
+	a.Len(records, 2)
+	a.Equal("access", records[0].Type)
+	a.Equal("cacheinfo", records[1].Type)
+}
+
+func Test_NewLogger_InvalidLevel(t *testing.T) {
+	_, err := NewLogger("loud", nil)
+	assert.Error(t, err)
+}
+
+func Test_Logger_RemoveHook(t *testing.T) {
+	configured, err := NewLogger("info", &LogConfig{Output: io.Discard})
+	assert.NoError(t, err)
+	plain := &Logger{Logger: logrus.New()}
+	plain.Out = io.Discard
+
+	for name, logger := range map[string]*Logger{"configured": configured, "plain": plain} {
+		t.Run(name, func(t *testing.T) {
+			removed, kept := &recordingHook{}, &recordingHook{}
+			logger.AddHook(removed)
+			logger.AddHook(kept)
+
+			logger.Info("first")
+			logger.RemoveHook(removed)
+			logger.Info("second")
+
+			assert.Equal(t, []string{"first"}, removed.messages)
+			assert.Equal(t, []string{"first", "second"}, kept.messages)
+		})
+	}
+}
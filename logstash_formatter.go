@@ -1,13 +1,12 @@
 package logging
 
 import (
-	"encoding/json"
-	"fmt"
+	"bytes"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
-	"github.com/snabble/go-logging/v2/tracex"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Taken from github.com/bshuster-repo/logrus-logstash-hook
@@ -24,6 +23,13 @@ type LogstashFormatter struct {
 
 	// Allow field renaming
 	FieldMap map[string]string
+
+	// GoogleCloudLogging adds the special fields of Google Cloud Logging and uses its severities.
+	// https://cloud.google.com/logging/docs/structured-logging#structured_logging_special_fields
+	GoogleCloudLogging bool
+
+	// GoogleProjectID is used to link entries to Cloud Trace, the trace is only linked if set.
+	GoogleProjectID string
 }
 
 func (f *LogstashFormatter) Format(entry *logrus.Entry) ([]byte, error) {
@@ -31,24 +37,31 @@ func (f *LogstashFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 }
 
 func (f *LogstashFormatter) FormatWithPrefix(entry *logrus.Entry, prefix string) ([]byte, error) {
-	fields := make(logrus.Fields)
+	fields := getJSONFields()
+	defer putJSONFields(fields)
+
 	for k, v := range entry.Data {
-		// remove the prefix when sending the fields to logstash
-		if prefix != "" && strings.HasPrefix(k, prefix) {
-			k = strings.TrimPrefix(k, prefix)
+		// Otherwise, errors are ignored by `encoding/json`
+		// https://github.com/Sirupsen/logrus/issues/377
+		var field jsonField
+		if err, ok := v.(error); ok {
+			field = jsonField{key: k, kind: jsonFieldString, str: err.Error()}
+		} else {
+			field = jsonField{key: k, value: v}
 		}
 
-		switch v := v.(type) {
-		case error:
-			// Otherwise, errors are ignored by `encoding/json`
-			// https://github.com/Sirupsen/logrus/issues/377
-			fields[k] = v.Error()
-		default:
-			fields[k] = v
+		if prefix == "" {
+			// the keys of entry.Data are unique, so there is no need to look for existing fields
+			fields.add(field)
+			continue
 		}
+
+		// remove the prefix when sending the fields to logstash
+		field.key = strings.TrimPrefix(k, prefix)
+		fields.put(field)
 	}
 
-	fields["@version"] = "1"
+	fields.setString("@version", "1")
 
 	timeStampFormat := f.TimestampFormat
 
@@ -56,43 +69,77 @@ func (f *LogstashFormatter) FormatWithPrefix(entry *logrus.Entry, prefix string)
 		timeStampFormat = time.RFC3339
 	}
 
-	fields["@timestamp"] = entry.Time.Format(timeStampFormat)
+	fields.setTime("@timestamp", entry.Time, timeStampFormat)
 
 	// set message field
 	v, ok := entry.Data["message"]
 	if ok {
-		fields["fields.message"] = v
+		fields.set("fields.message", v)
 	}
-	fields["message"] = entry.Message
+	fields.setString("message", entry.Message)
 
 	// set level field
 	v, ok = entry.Data["level"]
 	if ok {
-		fields["fields.level"] = v
+		fields.set("fields.level", v)
+	}
+	if f.GoogleCloudLogging {
+		fields.setString("level", googleSeverity(entry.Level))
+	} else {
+		fields.setString("level", levelName(entry.Level))
 	}
-	fields["level"] = entry.Level.String()
 
 	// set type field
 	if f.Type != "" {
 		v, ok = entry.Data["type"]
 		if ok {
-			fields["fields.type"] = v
+			fields.set("fields.type", v)
 		}
-		fields["type"] = f.Type
+		fields.setString("type", f.Type)
 	}
 
-	tracex.TraceAndSpan(entry.Context, fields)
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if spanContext.IsValid() {
+		traceID, spanID := spanContext.TraceID(), spanContext.SpanID()
+		fields.setHex("trace", traceID[:])
+		fields.setHex("span", spanID[:])
+	}
+
+	if f.GoogleCloudLogging {
+		addGoogleFields(entry, fields, f.GoogleProjectID)
+	}
 
 	for name, replacement := range f.FieldMap {
-		if value, ok := fields[name]; ok {
-			delete(fields, name)
-			fields[replacement] = value
-		}
+		fields.rename(name, replacement)
 	}
 
-	serialized, err := json.Marshal(fields)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal fields to JSON, %v", err)
+	fields.out = append(fields.appendJSON(fields.out[:0]), '\n')
+
+	b := entry.Buffer
+	if b == nil {
+		return bytes.Clone(fields.out), nil
+	}
+	b.Write(fields.out)
+	return b.Bytes(), nil
+}
+
+// levelName returns the same name as logrus.Level.String without allocating.
+func levelName(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel:
+		return "trace"
+	case logrus.DebugLevel:
+		return "debug"
+	case logrus.InfoLevel:
+		return "info"
+	case logrus.WarnLevel:
+		return "warning"
+	case logrus.ErrorLevel:
+		return "error"
+	case logrus.FatalLevel:
+		return "fatal"
+	case logrus.PanicLevel:
+		return "panic"
 	}
-	return append(serialized, '\n'), nil
+	return level.String()
 }
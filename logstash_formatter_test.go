@@ -2,12 +2,21 @@ package logging
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/snabble/go-logging/v2/tracex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Taken from github.com/bshuster-repo/logrus-logstash-hook
@@ -77,3 +86,197 @@ func TestLogstashFormatter(t *testing.T) {
 		t.Errorf("expected bool to be '%v' but got '%v'", true, data["bool"])
 	}
 }
+
+func Test_LogstashFormatter_IsCompatibleToMarshallingAMap(t *testing.T) {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+
+	for _, test := range []struct {
+		name      string
+		formatter LogstashFormatter
+		prefix    string
+		fields    logrus.Fields
+		message   string
+	}{
+		{
+			name:    "scalars",
+			fields:  logrus.Fields{"int": 1, "int64": int64(-7), "uint8": uint8(8), "pi": 3.14, "small": 1e-9, "big": float32(1e22), "bool": false, "nil": nil},
+			message: "msg",
+		},
+		{
+			name:    "escaping",
+			fields:  logrus.Fields{"html": "<a href=\"x\">&</a>", "control": "a\nb\tc\r\x00\x1f\b\f", "unicode": "ä€😀  ", "invalid": "a\xffb", "key \"quoted\"<>": "v"},
+			message: "line\nbreak <b>",
+		},
+		{
+			name:    "complex values",
+			fields:  logrus.Fields{"map": map[string]any{"b": 1, "a": []string{"x"}}, "time": time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), "duration": time.Second, "error": errors.New("oops"), "number": json.Number("12")},
+			message: "msg",
+		},
+		{
+			name:      "collisions and renames",
+			formatter: LogstashFormatter{Type: "abc", FieldMap: map[string]string{"field": "replacement", "@timestamp": "timestamp", "message": "msg"}},
+			fields:    logrus.Fields{"message": "def", "level": "ijk", "type": "lmn", "fields.message": "overwritten", "@version": "2", "field": "map", "replacement": "overwritten", "trace": "overwritten"},
+			message:   "msg",
+		},
+		{
+			name:    "prefix",
+			prefix:  "p.",
+			fields:  logrus.Fields{"p.a": 1, "b": 2, "p.message": "def"},
+			message: "msg",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			entry := logrus.WithFields(test.fields).WithContext(trace.ContextWithSpanContext(context.Background(), spanContext))
+			entry.Message = test.message
+			entry.Level = logrus.WarnLevel
+			entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+			expected, err := formatWithMap(&test.formatter, entry, test.prefix)
+			require.NoError(t, err)
+
+			actual, err := test.formatter.FormatWithPrefix(entry, test.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+
+			entry.Buffer = &bytes.Buffer{}
+			actual, err = test.formatter.FormatWithPrefix(entry, test.prefix)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(actual))
+		})
+	}
+}
+
+func Test_LogstashFormatter_UnsupportedValue(t *testing.T) {
+	entry := logrus.WithFields(logrus.Fields{"nan": math.NaN(), "one": 1})
+
+	b, err := (&LogstashFormatter{}).Format(entry)
+	require.NoError(t, err)
+
+	data := mapFromBuffer(bytes.NewBuffer(b))
+	assert.Equal(t, "!UNENCODABLE(float64): NaN", data["nan"])
+	assert.Equal(t, 1.0, data["one"])
+}
+
+func Test_LogstashFormatter_DoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops items randomly with the race detector")
+	}
+
+	formatter := &LogstashFormatter{TimestampFormat: time.RFC3339Nano}
+	entry := benchmarkEntry()
+	entry.Buffer = &bytes.Buffer{}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		entry.Buffer.Reset()
+		_, _ = formatter.Format(entry)
+	})
+	assert.Zero(t, allocs)
+}
+
+func BenchmarkLogstashFormatter(b *testing.B) {
+	formatter := &LogstashFormatter{TimestampFormat: time.RFC3339Nano}
+	entry := benchmarkEntry()
+	entry.Buffer = &bytes.Buffer{}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		entry.Buffer.Reset()
+		_, _ = formatter.Format(entry)
+	}
+}
+
+// BenchmarkLogstashFormatter_Map measures the previous implementation, which marshalled a map of the fields.
+func BenchmarkLogstashFormatter_Map(b *testing.B) {
+	formatter := &LogstashFormatter{TimestampFormat: time.RFC3339Nano}
+	entry := benchmarkEntry()
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = formatWithMap(formatter, entry, "")
+	}
+}
+
+func benchmarkEntry() *logrus.Entry {
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:  trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+	})
+
+	entry := logrus.WithFields(logrus.Fields{
+		TypeField:         TypeAccess,
+		"method":          "GET",
+		"url":             "/api/checkouts/123?limit=10",
+		"host":            "example.com",
+		"remote_ip":       "10.0.0.1",
+		"User_Agent":      "Mozilla/5.0 (X11; Linux x86_64)",
+		"proto":           "HTTP/1.1",
+		"response_status": 200,
+		DurationField:     int64(12),
+		"correlation_id":  "b7d1e5f0-0d9f-4c7e-8a7a-2a2f7c6c1f3e",
+	}).WithContext(trace.ContextWithSpanContext(context.Background(), spanContext))
+	entry.Message = "200 ->GET /api/checkouts/123?limit=10"
+	entry.Level = logrus.InfoLevel
+	entry.Time = time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	return entry
+}
+
+// formatWithMap is the previous implementation of LogstashFormatter.FormatWithPrefix without the
+// fields of Google Cloud Logging. It is used to verify the compatibility of the output.
+func formatWithMap(f *LogstashFormatter, entry *logrus.Entry, prefix string) ([]byte, error) {
+	fields := make(logrus.Fields)
+	for k, v := range entry.Data {
+		if prefix != "" && strings.HasPrefix(k, prefix) {
+			k = strings.TrimPrefix(k, prefix)
+		}
+
+		switch v := v.(type) {
+		case error:
+			fields[k] = v.Error()
+		default:
+			fields[k] = v
+		}
+	}
+
+	fields["@version"] = "1"
+
+	timeStampFormat := f.TimestampFormat
+	if timeStampFormat == "" {
+		timeStampFormat = time.RFC3339
+	}
+	fields["@timestamp"] = entry.Time.Format(timeStampFormat)
+
+	if v, ok := entry.Data["message"]; ok {
+		fields["fields.message"] = v
+	}
+	fields["message"] = entry.Message
+
+	if v, ok := entry.Data["level"]; ok {
+		fields["fields.level"] = v
+	}
+	fields["level"] = entry.Level.String()
+
+	if f.Type != "" {
+		if v, ok := entry.Data["type"]; ok {
+			fields["fields.type"] = v
+		}
+		fields["type"] = f.Type
+	}
+
+	tracex.TraceAndSpan(entry.Context, fields)
+
+	for name, replacement := range f.FieldMap {
+		if value, ok := fields[name]; ok {
+			delete(fields, name)
+			fields[replacement] = value
+		}
+	}
+
+	serialized, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return append(serialized, '\n'), nil
+}
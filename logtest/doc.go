@@ -0,0 +1,2 @@
+// Package logtest records the entries of a logging.Logger in tests and provides matchers to assert on them.
+package logtest
@@ -0,0 +1,139 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/snabble/go-logging/v2"
+)
+
+// Matcher matches recorded entries.
+type Matcher struct {
+	Description string
+	Match       func(entry Entry) bool
+}
+
+// Level matches entries with exactly the given level.
+func Level(level logrus.Level) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("level=%s", level),
+		Match:       func(entry Entry) bool { return entry.Level == level },
+	}
+}
+
+// LevelAtLeast matches entries with the given level or a more severe one.
+func LevelAtLeast(level logrus.Level) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("level>=%s", level),
+		Match:       func(entry Entry) bool { return entry.Level <= level },
+	}
+}
+
+// Message matches entries with exactly the given message.
+func Message(message string) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("message=%q", message),
+		Match:       func(entry Entry) bool { return entry.Message == message },
+	}
+}
+
+// MessageContains matches entries containing s in their message.
+func MessageContains(s string) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("message contains %q", s),
+		Match:       func(entry Entry) bool { return strings.Contains(entry.Message, s) },
+	}
+}
+
+// Type matches entries with the given logging.TypeField, e.g. logging.TypeAccess.
+func Type(t string) Matcher {
+	return Field(logging.TypeField, t)
+}
+
+// ResponseStatus matches access and call entries with the given response status.
+func ResponseStatus(status int) Matcher {
+	return Field("response_status", status)
+}
+
+// Field matches entries with the given field value. Values of different numeric types are considered
+// equal if they can be converted to each other.
+func Field(key string, value any) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("%s=%v", key, value),
+		Match: func(entry Entry) bool {
+			actual, ok := entry.Fields[key]
+			return ok && equalValues(value, actual)
+		},
+	}
+}
+
+// HasField matches entries having the field, regardless of its value.
+func HasField(key string) Matcher {
+	return Matcher{
+		Description: fmt.Sprintf("has %s", key),
+		Match: func(entry Entry) bool {
+			_, ok := entry.Fields[key]
+			return ok
+		},
+	}
+}
+
+// equalValues reports whether the values are deeply equal, or numbers or strings with the same value.
+func equalValues(expected, actual any) bool {
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	if expected == nil || actual == nil {
+		return false
+	}
+
+	e, a := reflect.ValueOf(expected), reflect.ValueOf(actual)
+	switch {
+	case e.Kind() == reflect.String && a.Kind() == reflect.String:
+		return e.String() == a.String()
+	case e.CanInt() && a.CanInt():
+		return e.Int() == a.Int()
+	case e.CanUint() && a.CanUint():
+		return e.Uint() == a.Uint()
+	case e.CanInt() && a.CanUint():
+		return e.Int() >= 0 && uint64(e.Int()) == a.Uint()
+	case e.CanUint() && a.CanInt():
+		return a.Int() >= 0 && e.Uint() == uint64(a.Int())
+	}
+
+	ef, eok := toFloat(e)
+	af, aok := toFloat(a)
+	return eok && aok && ef == af
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch {
+	case v.CanFloat():
+		return v.Float(), true
+	case v.CanInt():
+		return float64(v.Int()), true
+	case v.CanUint():
+		return float64(v.Uint()), true
+	}
+	return 0, false
+}
+
+func matchesAll(entry Entry, matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m.Match(entry) {
+			return false
+		}
+	}
+	return true
+}
+
+func describe(matchers []Matcher) string {
+	descriptions := make([]string, len(matchers))
+	for i, m := range matchers {
+		descriptions[i] = m.Description
+	}
+	return "[" + strings.Join(descriptions, ", ") + "]"
+}
@@ -0,0 +1,145 @@
+package logtest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/snabble/go-logging/v2"
+)
+
+// Entry is a recorded log entry.
+type Entry struct {
+	Level   logrus.Level
+	Message string
+	Time    time.Time
+	Fields  logrus.Fields
+}
+
+func (e Entry) String() string {
+	return fmt.Sprintf("%s: %s %v", e.Level, e.Message, e.Fields)
+}
+
+// Recorder records all entries of a Logger until the test finishes.
+type Recorder struct {
+	t testing.TB
+
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New installs a Recorder on the global logging.Log. Setting a new global Log in the test, e.g. with
+// logging.Set, detaches the Recorder.
+func New(t testing.TB) *Recorder {
+	t.Helper()
+	return NewForLogger(t, logging.Log)
+}
+
+// NewForLogger installs a Recorder on the given Logger. The Recorder is removed from the hooks of the
+// Logger when the test finishes.
+func NewForLogger(t testing.TB, logger *logging.Logger) *Recorder {
+	t.Helper()
+
+	r := &Recorder{t: t}
+	logger.AddHook(r)
+	t.Cleanup(func() {
+		logger.RemoveHook(r)
+	})
+
+	return r
+}
+
+func (r *Recorder) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (r *Recorder) Fire(entry *logrus.Entry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, Entry{
+		Level:   entry.Level,
+		Message: entry.Message,
+		Time:    entry.Time,
+		Fields:  maps.Clone(entry.Data),
+	})
+	return nil
+}
+
+// Entries returns all recorded entries.
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.entries)
+}
+
+// Reset removes all recorded entries.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = nil
+}
+
+// Find returns all recorded entries matching all matchers.
+func (r *Recorder) Find(matchers ...Matcher) []Entry {
+	var found []Entry
+	for _, entry := range r.Entries() {
+		if matchesAll(entry, matchers) {
+			found = append(found, entry)
+		}
+	}
+	return found
+}
+
+// AssertLogged fails the test if no recorded entry matches all matchers, otherwise the first match is returned.
+func (r *Recorder) AssertLogged(matchers ...Matcher) Entry {
+	r.t.Helper()
+
+	found := r.Find(matchers...)
+	if len(found) == 0 {
+		r.t.Errorf("expected an entry matching %s, recorded:\n%s", describe(matchers), r.describeEntries())
+		return Entry{}
+	}
+	return found[0]
+}
+
+// AssertNotLogged fails the test if a recorded entry matches all matchers.
+func (r *Recorder) AssertNotLogged(matchers ...Matcher) {
+	r.t.Helper()
+
+	if found := r.Find(matchers...); len(found) > 0 {
+		r.t.Errorf("expected no entry matching %s, found: %v", describe(matchers), found[0])
+	}
+}
+
+// FailOnUnexpectedErrors fails the test when it finishes, if entries with level error or above were recorded
+// which do not match any of the allowed matchers.
+func (r *Recorder) FailOnUnexpectedErrors(allowed ...Matcher) {
+	r.t.Helper()
+
+	r.t.Cleanup(func() {
+		for _, entry := range r.Find(LevelAtLeast(logrus.ErrorLevel)) {
+			if !slices.ContainsFunc(allowed, func(m Matcher) bool { return m.Match(entry) }) {
+				r.t.Errorf("unexpected error log: %v", entry)
+			}
+		}
+	})
+}
+
+func (r *Recorder) describeEntries() string {
+	var b strings.Builder
+	for _, entry := range r.Entries() {
+		b.WriteString("  ")
+		b.WriteString(entry.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
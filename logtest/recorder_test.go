@@ -0,0 +1,125 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snabble/go-logging/v2"
+)
+
+// fakeT records failures and cleanups instead of failing the surrounding test.
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+func newLogger(t *testing.T) *logging.Logger {
+	logger, err := logging.NewLogger("debug", &logging.LogConfig{Output: io.Discard})
+	require.NoError(t, err)
+	return logger
+}
+
+func Test_Recorder_RecordsEntriesAndMatches(t *testing.T) {
+	logger := newLogger(t)
+	recorder := NewForLogger(t, logger)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://www.example.org/foo", nil)
+	logger.Call(r, &http.Response{StatusCode: http.StatusNotFound}, time.Now(), nil)
+	logger.WithField("one", 1).Debug("debug message")
+
+	require.Len(t, recorder.Entries(), 2)
+
+	entry := recorder.AssertLogged(Type(logging.TypeCall), ResponseStatus(404), Level(logrus.WarnLevel))
+	assert.Equal(t, "404 GET-> http://www.example.org/foo", entry.Message)
+
+	recorder.AssertLogged(Message("debug message"), Field("one", int64(1)), HasField("one"))
+	recorder.AssertNotLogged(MessageContains("oops"))
+	assert.Len(t, recorder.Find(LevelAtLeast(logrus.InfoLevel)), 1)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Entries())
+}
+
+func Test_Recorder_ReportsFailures(t *testing.T) {
+	logger := newLogger(t)
+	ft := &fakeT{TB: t}
+	recorder := NewForLogger(ft, logger)
+
+	logger.Info("message")
+
+	recorder.AssertLogged(Message("other"))
+	recorder.AssertNotLogged(Message("message"))
+
+	require.Len(t, ft.errors, 2)
+	assert.Contains(t, ft.errors[0], `message="other"`)
+	assert.Contains(t, ft.errors[0], "info: message")
+}
+
+func Test_Recorder_FailOnUnexpectedErrors(t *testing.T) {
+	logger := newLogger(t)
+	ft := &fakeT{TB: t}
+	recorder := NewForLogger(ft, logger)
+	recorder.FailOnUnexpectedErrors(Message("expected failure"))
+
+	logger.WithError(errors.New("boom")).Error("expected failure")
+	logger.Warn("warnings are fine")
+	logger.Error("unexpected failure")
+
+	ft.finish()
+
+	require.Len(t, ft.errors, 1)
+	assert.Contains(t, ft.errors[0], "unexpected failure")
+}
+
+func Test_Recorder_StopsRecordingAfterTest(t *testing.T) {
+	logger := newLogger(t)
+
+	ft := &fakeT{TB: t}
+	recorder := NewForLogger(ft, logger)
+	logger.Info("during test")
+
+	ft.finish()
+	logger.Info("after cleanup")
+
+	require.Len(t, recorder.Entries(), 1)
+	assert.Equal(t, "during test", recorder.Entries()[0].Message)
+}
+
+func Test_Field_ComparesNumbersByValue(t *testing.T) {
+	entry := Entry{Fields: logrus.Fields{"int": 200, "float": 1.5, "uint": uint8(3), "string": "a"}}
+
+	assert.True(t, Field("int", int64(200)).Match(entry))
+	assert.True(t, Field("int", 200.0).Match(entry))
+	assert.True(t, Field("float", float32(1.5)).Match(entry))
+	assert.True(t, Field("uint", 3).Match(entry))
+	assert.True(t, Field("string", "a").Match(entry))
+	assert.False(t, Field("int", 201).Match(entry))
+	assert.False(t, Field("int", "200").Match(entry))
+	assert.False(t, Field("uint", -3).Match(entry))
+	assert.False(t, Field("missing", nil).Match(entry))
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package logging
+
+// raceEnabled reports whether the tests run with the race detector, which makes sync.Pool drop items.
+const raceEnabled = false
@@ -0,0 +1,98 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/snabble/go-logging/v2/tracex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_OTelLogs_EmitsFilteredAndRedactedEntries(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+
+	logProvider, err := tracex.NewOTLPLogProvider(context.Background(), tracex.NewResource("sampleApp", "v1.0.0"),
+		otlploghttp.WithEndpointURL(receiver.URL+"/v1/logs"))
+	require.NoError(t, err)
+	defer logProvider.Shutdown(context.Background())
+
+	logger, err := NewLogger("info", &LogConfig{
+		Output:      io.Discard,
+		OTelLogs:    logProvider,
+		ScopeLevels: map[string]logrus.Level{"payment": logrus.DebugLevel},
+		Redaction:   &RedactionConfig{Fields: []string{"password"}},
+	})
+	require.NoError(t, err)
+
+	logger.WithScope("payment").WithField("password", "secret").Debug("payment debug")
+	logger.WithScope("checkout").Debug("checkout debug")
+
+	// Flush exports the pending records of the provider
+	require.NoError(t, logger.Flush(context.Background()))
+
+	var records []*logspb.LogRecord
+	for _, resourceLogs := range receiver.resourceLogs() {
+		for _, scopeLogs := range resourceLogs.ScopeLogs {
+			records = append(records, scopeLogs.LogRecords...)
+		}
+	}
+	require.Len(t, records, 1)
+	assert.Equal(t, "payment debug", records[0].Body.GetStringValue())
+	attributes := map[string]string{}
+	for _, kv := range records[0].Attributes {
+		attributes[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, defaultRedactionMask, attributes["password"])
+	assert.Equal(t, "payment", attributes[ScopeField])
+}
+
+type otlpReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*collogs.ExportLogsServiceRequest
+}
+
+func newOTLPReceiver(t *testing.T) *otlpReceiver {
+	t.Helper()
+
+	receiver := &otlpReceiver{}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		request := &collogs.ExportLogsServiceRequest{}
+		if !assert.NoError(t, err) || !assert.NoError(t, proto.Unmarshal(body, request)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, request)
+		receiver.mu.Unlock()
+
+		response, _ := proto.Marshal(&collogs.ExportLogsServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *otlpReceiver) resourceLogs() []*logspb.ResourceLogs {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*logspb.ResourceLogs
+	for _, request := range r.requests {
+		result = append(result, request.ResourceLogs...)
+	}
+	return result
+}
@@ -0,0 +1,366 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const defaultRedactionMask = "[REDACTED]"
+
+// RedactionConfig masks sensitive data before entries are passed to hooks and formatters.
+type RedactionConfig struct {
+	// Fields are the names of fields whose values are masked completely, case-insensitive and on all
+	// levels of nested maps.
+	Fields []string
+	// Patterns are matched against all string values and the message, matches are replaced by Mask.
+	Patterns []*regexp.Regexp
+	// Detectors are matched against all string values and the message, see DefaultDetectors.
+	Detectors []Detector
+	// Mask replaces the values of Fields and the matches of Patterns, defaults to "[REDACTED]".
+	Mask string
+}
+
+func (c *RedactionConfig) mask() string {
+	if c.Mask != "" {
+		return c.Mask
+	}
+	return defaultRedactionMask
+}
+
+// Detector finds sensitive data in string values and replaces it with a masked form.
+type Detector struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Validate filters matches of Pattern, all matches are replaced if nil.
+	Validate func(match string) bool
+	// Mask returns the replacement for a match.
+	Mask func(match string) string
+}
+
+func (d Detector) redact(s string) string {
+	return d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+		if d.Validate != nil && !d.Validate(match) {
+			return match
+		}
+		return d.Mask(match)
+	})
+}
+
+var (
+	// DetectPAN masks Luhn-valid payment card numbers except for the last four digits.
+	DetectPAN = Detector{
+		Name:     "pan",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: isLuhnValid,
+		Mask: func(match string) string {
+			digits := onlyDigits(match)
+			return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+		},
+	}
+
+	// DetectIBAN masks IBANs with a valid checksum except for the country code and the last four characters.
+	DetectIBAN = Detector{
+		Name:     "iban",
+		Pattern:  regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}\b`),
+		Validate: isIBANValid,
+		Mask: func(match string) string {
+			iban := strings.ReplaceAll(match, " ", "")
+			return iban[:2] + strings.Repeat("*", len(iban)-6) + iban[len(iban)-4:]
+		},
+	}
+
+	// DetectEmail masks the local part of email addresses except for the first character.
+	DetectEmail = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		Mask: func(match string) string {
+			local, domain, _ := strings.Cut(match, "@")
+			return local[:1] + "***@" + domain
+		},
+	}
+
+	// DetectJWT masks JSON web tokens completely.
+	DetectJWT = Detector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]*\.eyJ[A-Za-z0-9_-]*\.[A-Za-z0-9_-]*`),
+		Mask: func(string) string {
+			return "[REDACTED JWT]"
+		},
+	}
+
+	// DetectBearerToken masks the token of bearer authorization values.
+	DetectBearerToken = Detector{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+		Mask: func(match string) string {
+			return match[:len("bearer")] + " " + defaultRedactionMask
+		},
+	}
+)
+
+// DefaultDetectors returns all built-in detectors.
+func DefaultDetectors() []Detector {
+	return []Detector{DetectJWT, DetectBearerToken, DetectPAN, DetectIBAN, DetectEmail}
+}
+
+type redactor struct {
+	fields    map[string]bool
+	patterns  []*regexp.Regexp
+	detectors []Detector
+	mask      string
+}
+
+func newRedactor(config RedactionConfig) *redactor {
+	r := &redactor{
+		fields:    map[string]bool{},
+		patterns:  config.Patterns,
+		detectors: config.Detectors,
+		mask:      config.mask(),
+	}
+	for _, field := range config.Fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// redactString applies the patterns and detectors to s.
+func (r *redactor) redactString(s string) string {
+	for _, pattern := range r.patterns {
+		s = pattern.ReplaceAllString(s, r.mask)
+	}
+	for _, detector := range r.detectors {
+		s = detector.redact(s)
+	}
+	return s
+}
+
+// redactValue returns the redacted form of value. Nested maps and slices are copied, so values owned by
+// the caller are never modified.
+func (r *redactor) redactValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return r.redactString(v)
+	case error:
+		redacted := r.redactString(v.Error())
+		if redacted == v.Error() {
+			return v
+		}
+		return redacted
+	case logrus.Fields:
+		return logrus.Fields(r.redactMap(v))
+	case map[string]any:
+		return r.redactMap(v)
+	case map[string]string:
+		result := make(map[string]string, len(v))
+		for key, item := range v {
+			if r.fields[strings.ToLower(key)] {
+				result[key] = r.mask
+			} else {
+				result[key] = r.redactString(item)
+			}
+		}
+		return result
+	case []string:
+		result := make([]string, len(v))
+		for i, item := range v {
+			result[i] = r.redactString(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = r.redactValue(item)
+		}
+		return result
+	case json.Number:
+		if redacted := r.redactString(v.String()); redacted != v.String() {
+			return redacted
+		}
+		return v
+	case nil, bool, time.Time, time.Duration:
+		return value
+	}
+	return r.redactOther(value)
+}
+
+// redactOther redacts the values not handled by redactValue. Numbers and fmt.Stringer values are
+// redacted in their string form, e.g. card numbers stored as int64. Byte slices containing json and
+// structs, pointers and other maps and slices are redacted in the form they are encoded as json.
+// The value is returned unchanged, if nothing is redacted.
+func (r *redactor) redactOther(value any) any {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer && v.IsNil() {
+		return value
+	}
+
+	// byte slices like json.RawMessage
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		data := v.Bytes()
+		if !json.Valid(data) {
+			return r.redactStringForm(value, string(data))
+		}
+		if redacted, changed := r.redactJSON(data); changed {
+			return redacted
+		}
+		return value
+	}
+
+	if stringer, ok := value.(fmt.Stringer); ok {
+		if redacted := r.redactString(stringer.String()); redacted != stringer.String() {
+			return redacted
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if redacted := r.redactString(v.String()); redacted != v.String() {
+			return redacted
+		}
+		return value
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.redactStringForm(value, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return r.redactStringForm(value, strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		return r.redactStringForm(value, strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	case reflect.Bool, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return value
+	}
+
+	encodable := value
+	if safe, changed := jsonSafeValue(v); changed {
+		encodable = safe
+	}
+	data, err := json.Marshal(encodable)
+	if err != nil {
+		return value
+	}
+	if redacted, changed := r.redactJSON(data); changed {
+		return redacted
+	}
+	return value
+}
+
+// redactStringForm redacts the string form of value, e.g. a number or a byte slice. It returns value
+// unchanged, if nothing is redacted.
+func (r *redactor) redactStringForm(value any, form string) any {
+	if redacted := r.redactString(form); redacted != form {
+		return redacted
+	}
+	return value
+}
+
+// redactJSON decodes data into generic maps and slices and redacts them. It returns false, if data
+// is no valid json or nothing is redacted.
+func (r *redactor) redactJSON(data []byte) (any, bool) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, false
+	}
+	redacted := r.redactValue(decoded)
+	if reflect.DeepEqual(decoded, redacted) {
+		return nil, false
+	}
+	return redacted, true
+}
+
+func (r *redactor) redactMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for key, value := range m {
+		if r.fields[strings.ToLower(key)] {
+			result[key] = r.mask
+		} else {
+			result[key] = r.redactValue(value)
+		}
+	}
+	return result
+}
+
+// redactionHook redacts the message and fields of the entries. It is registered right after the
+// contextFieldsHook, so the fields added from the context are redacted as well and neither the
+// other hooks nor the formatters see the original values.
+type redactionHook struct {
+	redactor *redactor
+}
+
+func (h *redactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *redactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.redactor.redactString(entry.Message)
+	// entry.Data is a copy owned by the entry being logged, so it can be modified in place
+	for key, value := range entry.Data {
+		if h.redactor.fields[strings.ToLower(key)] {
+			entry.Data[key] = h.redactor.mask
+		} else {
+			entry.Data[key] = h.redactor.redactValue(value)
+		}
+	}
+	return nil
+}
+
+func isLuhnValid(s string) bool {
+	digits := onlyDigits(s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func isIBANValid(s string) bool {
+	iban := strings.ReplaceAll(s, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// move the country code and checksum to the end and compute mod 97 digit by digit
+	rearranged := iban[4:] + iban[:4]
+	remainder := 0
+	for _, c := range rearranged {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A'+10)) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
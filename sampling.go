@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingConfig limits the number of identical entries. Per Tick, the first Initial entries with the same key
+// are logged, afterward only every Thereafter-th entry. The number of suppressed entries is reported in the
+// CountField of the next logged entry with the same key.
+type SamplingConfig struct {
+	// Initial is the number of entries per key and tick that are always logged.
+	Initial int
+	// Thereafter defines that every Thereafter-th entry after Initial is logged, 0 drops all of them.
+	Thereafter int
+	// Tick is the interval after which the counters are reset, defaults to one second.
+	Tick time.Duration
+	// Key returns the key entries are grouped by, defaults to the level and message of the entry.
+	// Entries are sampled before the hooks fire, so fields added by hooks are not set yet.
+	Key func(entry *logrus.Entry) string
+	// Levels restricts the sampling to the given levels. If empty, all levels except error, fatal and
+	// panic are sampled.
+	Levels []logrus.Level
+}
+
+func (c *SamplingConfig) validate() error {
+	if c.Initial < 0 || c.Thereafter < 0 {
+		return errors.New("invalid sampling config: Initial and Thereafter must not be negative")
+	}
+	if c.Initial == 0 && c.Thereafter == 0 {
+		return errors.New("invalid sampling config: Initial or Thereafter must be set, otherwise all entries are dropped")
+	}
+	return nil
+}
+
+func (c *SamplingConfig) samples(level logrus.Level) bool {
+	if len(c.Levels) == 0 {
+		return level > logrus.ErrorLevel
+	}
+	return slices.Contains(c.Levels, level)
+}
+
+func (c *SamplingConfig) tick() time.Duration {
+	if c.Tick > 0 {
+		return c.Tick
+	}
+	return time.Second
+}
+
+func (c *SamplingConfig) key(entry *logrus.Entry) string {
+	if c.Key != nil {
+		return c.Key(entry)
+	}
+	return entry.Level.String() + "|" + entry.Message
+}
+
+type sampler struct {
+	config SamplingConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*sampleCounter
+	sweepAt  time.Time
+}
+
+type sampleCounter struct {
+	resetAt    time.Time
+	count      int
+	suppressed int
+}
+
+func newSampler(config SamplingConfig) *sampler {
+	return &sampler{
+		config:   config,
+		now:      time.Now,
+		counters: map[string]*sampleCounter{},
+	}
+}
+
+// sample decides if the entry is logged. For logged entries it returns the number of entries
+// suppressed since the last logged entry with the same key.
+func (s *sampler) sample(entry *logrus.Entry) (bool, int) {
+	if !s.config.samples(entry.Level) {
+		return true, 0
+	}
+
+	key := s.config.key(entry)
+	now := s.now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	counter, ok := s.counters[key]
+	if !ok {
+		counter = &sampleCounter{}
+		s.counters[key] = counter
+	}
+	if !now.Before(counter.resetAt) {
+		counter.resetAt = now.Add(s.config.tick())
+		counter.count = 0
+	}
+	counter.count++
+
+	if counter.count <= s.config.Initial ||
+		(s.config.Thereafter > 0 && (counter.count-s.config.Initial)%s.config.Thereafter == 0) {
+		suppressed := counter.suppressed
+		counter.suppressed = 0
+		return true, suppressed
+	}
+
+	counter.suppressed++
+	return false, 0
+}
+
+// sweep removes the counters of expired ticks without suppressed entries, so the map does not grow with
+// every message ever logged.
+func (s *sampler) sweep(now time.Time) {
+	if now.Before(s.sweepAt) {
+		return
+	}
+	s.sweepAt = now.Add(s.config.tick())
+
+	for key, counter := range s.counters {
+		if counter.suppressed == 0 && !now.Before(counter.resetAt) {
+			delete(s.counters, key)
+		}
+	}
+}
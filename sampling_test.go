@@ -0,0 +1,150 @@
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sampling_FirstNThenEveryMth(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, SetWithConfig("info", &LogConfig{
+		Sampling: &SamplingConfig{Initial: 2, Thereafter: 3, Tick: time.Hour},
+	}))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	for i := 0; i < 7; i++ {
+		Log.Warn("retrying")
+	}
+	Log.Info("retrying")
+
+	records := logRecordsFromBuffer(b)
+	require.Len(t, records, 4)
+	assert.Equal(t, "warning", records[2].Level)
+	assert.Equal(t, "info", records[3].Level)
+
+	data := mapsFromBuffer(b)
+	assert.Nil(t, data[0][CountField])
+	assert.Nil(t, data[1][CountField])
+	assert.Equal(t, 2.0, data[2][CountField])
+	assert.Nil(t, data[3][CountField])
+}
+
+func Test_Sampling_CustomKeyAndLevels(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, SetWithConfig("info", &LogConfig{
+		Sampling: &SamplingConfig{
+			Initial: 1,
+			Tick:    time.Hour,
+			Key:     func(entry *logrus.Entry) string { return fmt.Sprint(entry.Data["upstream"]) },
+			Levels:  []logrus.Level{logrus.WarnLevel},
+		},
+	}))
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	for i := 0; i < 3; i++ {
+		Log.WithField("upstream", "a").Warnf("attempt %d", i)
+		Log.WithField("upstream", "b").Warnf("attempt %d", i)
+		Log.WithField("upstream", "a").Errorf("attempt %d", i)
+	}
+
+	assert.Len(t, logRecordsFromBuffer(b), 5)
+}
+
+func Test_Sampling_HooksOnlyFireForLoggedEntries(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{
+		Output:   b,
+		Sampling: &SamplingConfig{Initial: 1, Thereafter: 2, Tick: time.Hour},
+	})
+	require.NoError(t, err)
+
+	hook := &recordingHook{}
+	logger.AddHook(hook)
+
+	for i := 0; i < 5; i++ {
+		logger.Warn("retrying")
+	}
+
+	require.Len(t, hook.data, 3)
+	assert.Nil(t, hook.data[0][CountField])
+	assert.Equal(t, 1, hook.data[1][CountField])
+	assert.Equal(t, 1, hook.data[2][CountField])
+	assert.Len(t, logRecordsFromBuffer(b), 3)
+}
+
+func Test_Sampling_SetFormatterKeepsFiltering(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{
+		Output:   b,
+		Sampling: &SamplingConfig{Initial: 1, Tick: time.Hour},
+	})
+	require.NoError(t, err)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+
+	for i := 0; i < 100; i++ {
+		logger.Warn("retrying")
+	}
+
+	assert.Len(t, logRecordsFromBuffer(b), 1)
+}
+
+func Test_Sampling_InvalidConfig(t *testing.T) {
+	for _, config := range []SamplingConfig{{}, {Initial: -1, Thereafter: 1}, {Initial: 1, Thereafter: -1}} {
+		_, err := NewLogger("info", &LogConfig{Sampling: &config})
+		assert.ErrorContains(t, err, "invalid sampling config")
+	}
+}
+
+func Test_Sampling_ErrorsAreNotSampledByDefault(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{
+		Output:   b,
+		Sampling: &SamplingConfig{Initial: 1, Tick: time.Hour},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		logger.Error("failed")
+		logger.Warn("retrying")
+	}
+
+	assert.Len(t, logRecordsFromBuffer(b), 4)
+}
+
+func Test_Sampler_ResetsAfterTick(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := newSampler(SamplingConfig{Initial: 1})
+	s.now = func() time.Time { return now }
+
+	entry := &logrus.Entry{Level: logrus.WarnLevel, Message: "retrying"}
+
+	keep, _ := s.sample(entry)
+	assert.True(t, keep)
+	keep, _ = s.sample(entry)
+	assert.False(t, keep)
+
+	now = now.Add(time.Second)
+	keep, suppressed := s.sample(entry)
+	assert.True(t, keep)
+	assert.Equal(t, 1, suppressed)
+
+	now = now.Add(2 * time.Second)
+	s.sample(&logrus.Entry{Level: logrus.WarnLevel, Message: "other"})
+	assert.Len(t, s.counters, 1)
+}
+
+func mapsFromBuffer(b *bytes.Buffer) []map[string]any {
+	var result []map[string]any
+	for _, line := range bytes.Split(bytes.TrimRight(b.Bytes(), "\n"), []byte("\n")) {
+		result = append(result, mapFromBuffer(bytes.NewBuffer(line)))
+	}
+	return result
+}
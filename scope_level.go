@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ParseScopeLevels parses a comma separated list of scope levels like "payment=debug,cache=warn".
+func ParseScopeLevels(s string) (map[string]logrus.Level, error) {
+	levels := map[string]logrus.Level{}
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		scope, levelName, ok := strings.Cut(pair, "=")
+		scope = strings.TrimSpace(scope)
+		if !ok || scope == "" {
+			return nil, fmt.Errorf("invalid scope level: '%s', expected scope=level", pair)
+		}
+
+		level, err := logrus.ParseLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return nil, fmt.Errorf("invalid scope level: '%s': %w", pair, err)
+		}
+		levels[scope] = level
+	}
+
+	return levels, nil
+}
+
+// scopeLevels holds the level of a Logger together with the level overrides per scope.
+// The level of the underlying logrus.Logger is set to the most verbose of all levels,
+// entries are then filtered by the level of their most specific scope, see entryFilter.
+type scopeLevels struct {
+	base   atomic.Uint32
+	levels map[string]logrus.Level
+}
+
+func newScopeLevels(base logrus.Level, levels map[string]logrus.Level) *scopeLevels {
+	s := &scopeLevels{levels: levels}
+	s.base.Store(uint32(base))
+	return s
+}
+
+func (s *scopeLevels) baseLevel() logrus.Level {
+	return logrus.Level(s.base.Load())
+}
+
+func (s *scopeLevels) setBaseLevel(level logrus.Level) {
+	s.base.Store(uint32(level))
+}
+
+// threshold returns the most verbose of all configured levels.
+func (s *scopeLevels) threshold() logrus.Level {
+	threshold := s.baseLevel()
+	for _, level := range s.levels {
+		threshold = max(threshold, level)
+	}
+	return threshold
+}
+
+// levelFor returns the level of the most specific scope matching the given one. Scopes are
+// hierarchical, separated by "." or "/", so "payment" matches "payment.refund" as well.
+func (s *scopeLevels) levelFor(scope string) logrus.Level {
+	matched := ""
+	level := s.baseLevel()
+
+	for candidate, candidateLevel := range s.levels {
+		if len(candidate) <= len(matched) || !scopeMatches(scope, candidate) {
+			continue
+		}
+		matched = candidate
+		level = candidateLevel
+	}
+
+	return level
+}
+
+func (s *scopeLevels) isEnabled(entry *logrus.Entry) bool {
+	scope, _ := entry.Data[ScopeField].(string)
+	return s.levelFor(scope) >= entry.Level
+}
+
+func scopeMatches(scope, candidate string) bool {
+	if !strings.HasPrefix(scope, candidate) {
+		return false
+	}
+
+	rest := scope[len(candidate):]
+	return rest == "" || rest[0] == '.' || rest[0] == '/'
+}
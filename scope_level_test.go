@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"maps"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseScopeLevels(t *testing.T) {
+	levels, err := ParseScopeLevels(" payment=debug, cache=warn ,")
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]logrus.Level{"payment": logrus.DebugLevel, "cache": logrus.WarnLevel}, levels)
+}
+
+func Test_ParseScopeLevels_Invalid(t *testing.T) {
+	for _, value := range []string{"payment", "=debug", "payment=loud"} {
+		_, err := ParseScopeLevels(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func Test_ScopeLevels_FilterByMostSpecificScope(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, SetWithConfig("info", &LogConfig{
+		ScopeLevels: map[string]logrus.Level{
+			"payment":        logrus.DebugLevel,
+			"payment.refund": logrus.ErrorLevel,
+			"cache":          logrus.WarnLevel,
+		},
+	}))
+
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+
+	Log.Debug("global debug")
+	Log.Info("global info")
+	Log.WithScope("payment").Debug("payment debug")
+	Log.WithScope("payment/stripe").Debug("payment stripe debug")
+	Log.WithScope("payment.refund").Warn("refund warn")
+	Log.WithScope("paymentx").Debug("paymentx debug")
+	Log.WithScope("cache").Info("cache info")
+	Log.WithScope("cache").Warn("cache warn")
+
+	var messages []string
+	for _, record := range logRecordsFromBuffer(b) {
+		messages = append(messages, record.Message)
+	}
+	assert.Equal(t, []string{"global info", "payment debug", "payment stripe debug", "cache warn"}, messages)
+}
+
+func Test_ScopeLevels_SetLevelKeepsScopes(t *testing.T) {
+	defer Set("info", true)
+	require.NoError(t, SetWithConfig("info", &LogConfig{
+		ScopeLevels: map[string]logrus.Level{"payment": logrus.DebugLevel},
+	}))
+
+	Log.SetLevel(logrus.ErrorLevel)
+
+	assert.Equal(t, logrus.ErrorLevel, Log.GetLevel())
+	assert.False(t, Log.IsLevelEnabled(logrus.WarnLevel))
+	assert.False(t, Log.IsScopeLevelEnabled("checkout", logrus.WarnLevel))
+	assert.True(t, Log.IsScopeLevelEnabled("payment.refund", logrus.DebugLevel))
+
+	b := bytes.NewBuffer(nil)
+	Log.Out = b
+	Log.Warn("global warn")
+	Log.WithScope("payment").Debug("payment debug")
+
+	assert.Equal(t, "payment debug", logRecordFromBuffer(b).Message)
+}
+
+type recordingHook struct {
+	messages []string
+	data     []logrus.Fields
+}
+
+func (h *recordingHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *recordingHook) Fire(entry *logrus.Entry) error {
+	h.messages = append(h.messages, entry.Message)
+	h.data = append(h.data, maps.Clone(entry.Data))
+	return nil
+}
+
+func Test_ScopeLevels_HooksOnlyFireForLoggedEntries(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{
+		Output:       b,
+		EnableTraces: true,
+		ScopeLevels:  map[string]logrus.Level{"payment": logrus.DebugLevel},
+	})
+	require.NoError(t, err)
+
+	hook := &recordingHook{}
+	logger.AddHook(hook)
+
+	logger.Debug("global debug")
+	logger.WithScope("payment").Debug("payment debug")
+	logger.WithScope("checkout").Debug("checkout debug")
+	logger.WithScope("checkout").Info("checkout info")
+
+	assert.Equal(t, []string{"payment debug", "checkout info"}, hook.messages)
+	records := logRecordsFromBuffer(b)
+	require.Len(t, records, 2)
+	assert.Equal(t, "payment debug", records[0].Message)
+	assert.Equal(t, "checkout info", records[1].Message)
+}
@@ -0,0 +1,100 @@
+package logging
+
+import (
+	"errors"
+	"io"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SinkFilter selects the entries written to a Sink.
+type SinkFilter func(entry *logrus.Entry) bool
+
+// TypeIs matches the entries with one of the types in TypeField, e.g. TypeAccess.
+func TypeIs(types ...string) SinkFilter {
+	return func(entry *logrus.Entry) bool {
+		t, _ := entry.Data[TypeField].(string)
+		return slices.Contains(types, t)
+	}
+}
+
+// Not matches the entries which are not matched by filter.
+func Not(filter SinkFilter) SinkFilter {
+	return func(entry *logrus.Entry) bool {
+		return !filter(entry)
+	}
+}
+
+// Sink is one of the outputs of a Logger. Every entry is written to all matching sinks.
+type Sink struct {
+	// Output is the writer the entries are written to, defaults to LogConfig.Output.
+	Output io.Writer
+	// Formatter formats the entries of the sink, defaults to the formatter selected by the LogConfig.
+	Formatter logrus.Formatter
+	// Level is the minimum level of the entries, e.g. logrus.ErrorLevel for errors and more severe
+	// entries. All entries enabled by the level of the Logger are written if not set.
+	Level *logrus.Level
+	// Filter selects the entries, all entries are written if not set.
+	Filter SinkFilter
+}
+
+func (s *Sink) matches(entry *logrus.Entry) bool {
+	if s.Level != nil && entry.Level > *s.Level {
+		return false
+	}
+	return s.Filter == nil || s.Filter(entry)
+}
+
+// sinkRouter formats the entries for each matching sink and writes them to the outputs of the sinks.
+// It returns nothing to logrus, so nothing is written to the output of the Logger.
+type sinkRouter struct {
+	sinks []Sink
+	async *asyncWriter
+}
+
+func newSinkRouter(sinks []Sink, config *LogConfig, out io.Writer, formatter logrus.Formatter) *sinkRouter {
+	router := &sinkRouter{}
+	for _, sink := range sinks {
+		if sink.Output == nil {
+			sink.Output = out
+		}
+		if sink.Formatter == nil {
+			sink.Formatter = formatter
+		} else if config.Limits != nil {
+			sink.Formatter = &limitFormatter{Formatter: sink.Formatter, limits: *config.Limits}
+		}
+		router.sinks = append(router.sinks, sink)
+	}
+	return router
+}
+
+func (r *sinkRouter) Format(entry *logrus.Entry) ([]byte, error) {
+	var errs []error
+	for i := range r.sinks {
+		sink := &r.sinks[i]
+		if !sink.matches(entry) {
+			continue
+		}
+
+		// the buffer of the entry is shared by the sinks
+		if entry.Buffer != nil {
+			entry.Buffer.Reset()
+		}
+		serialized, err := sink.Formatter.Format(entry)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(serialized) == 0 {
+			continue
+		}
+
+		if r.async != nil {
+			r.async.enqueueTo(sink.Output, entry.Level, serialized)
+		} else if _, err := sink.Output.Write(serialized); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return nil, errors.Join(errs...)
+}
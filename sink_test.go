@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Sinks_RouteEntriesByTypeAndLevel(t *testing.T) {
+	access, errs, other := bytes.NewBuffer(nil), bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	errorLevel := logrus.ErrorLevel
+
+	logger, err := NewLogger("info", &LogConfig{
+		Sinks: []Sink{
+			{Output: access, Filter: TypeIs(TypeAccess)},
+			{Output: errs, Level: &errorLevel, Filter: Not(TypeIs(TypeAccess)), Formatter: &LogfmtFormatter{}},
+			{Output: other, Filter: func(entry *logrus.Entry) bool {
+				return entry.Level > logrus.ErrorLevel && !TypeIs(TypeAccess)(entry)
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	logger.Access(httptest.NewRequest("GET", "/path", nil), time.Now(), 500)
+	logger.Error("failed")
+	logger.Info("info")
+	logger.Debug("not enabled")
+
+	accessEntries := mapsFromBuffer(access)
+	require.Len(t, accessEntries, 1)
+	assert.Equal(t, TypeAccess, accessEntries[0][TypeField])
+
+	assert.Contains(t, errs.String(), `level=error msg=failed`)
+	assert.Equal(t, 1, bytes.Count(errs.Bytes(), []byte("\n")))
+
+	otherEntries := mapsFromBuffer(other)
+	require.Len(t, otherEntries, 1)
+	assert.Equal(t, "info", otherEntries[0]["message"])
+}
+
+func Test_Sinks_DefaultToOutput(t *testing.T) {
+	b := bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{Output: b, Sinks: []Sink{{}, {Filter: TypeIs(TypeLifecycle)}}})
+	require.NoError(t, err)
+
+	logger.Info("info")
+
+	entries := mapsFromBuffer(b)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "info", entries[0]["message"])
+}
+
+func Test_Sinks_Async(t *testing.T) {
+	first, second := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	logger, err := NewLogger("info", &LogConfig{
+		Async: &AsyncConfig{},
+		Sinks: []Sink{{Output: first}, {Output: second, Formatter: &LogfmtFormatter{}}},
+	})
+	require.NoError(t, err)
+
+	logger.Info("message")
+	require.NoError(t, logger.Flush(context.Background()))
+
+	assert.Equal(t, "message", mapFromBuffer(first)["message"])
+	assert.Contains(t, second.String(), "msg=message")
+}
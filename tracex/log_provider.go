@@ -0,0 +1,188 @@
+package tracex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	sdklog "go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/resource"
+)
+
+// instrumentationScope is the name of the otel logger the entries are emitted with.
+const instrumentationScope = "github.com/snabble/go-logging"
+
+// LogProvider emits the log entries as otel log records, e.g. to an OTLP collector.
+type LogProvider struct {
+	lp     *sdklog.LoggerProvider
+	logger log.Logger
+}
+
+// NewLogProvider creates a LogProvider which exports the records in batches with the exporter.
+func NewLogProvider(appResource *resource.Resource, exporter sdklog.Exporter) *LogProvider {
+	lp := sdklog.NewLoggerProvider(
+		sdklog.WithResource(appResource),
+		sdklog.WithProcessor(sdklog.NewBatchProcessor(exporter)),
+	)
+	return &LogProvider{lp: lp, logger: lp.Logger(instrumentationScope)}
+}
+
+// NewOTLPLogProvider creates a LogProvider which exports the records with OTLP/HTTP. The endpoint
+// defaults to the environment variables OTEL_EXPORTER_OTLP_LOGS_ENDPOINT and OTEL_EXPORTER_OTLP_ENDPOINT.
+func NewOTLPLogProvider(ctx context.Context, appResource *resource.Resource, options ...otlploghttp.Option) (*LogProvider, error) {
+	exporter, err := otlploghttp.New(ctx, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create otlp log exporter: %w", err)
+	}
+	return NewLogProvider(appResource, exporter), nil
+}
+
+// NewOTLPLogProvider creates a LogProvider with the same resource attributes as the traces.
+func (p *TraceProvider) NewOTLPLogProvider(ctx context.Context, options ...otlploghttp.Option) (*LogProvider, error) {
+	return NewOTLPLogProvider(ctx, p.resource, options...)
+}
+
+// ForceFlush exports all records which are not exported yet.
+func (p *LogProvider) ForceFlush(ctx context.Context) error {
+	return p.lp.ForceFlush(ctx)
+}
+
+// Shutdown exports the remaining records and stops the provider.
+func (p *LogProvider) Shutdown(ctx context.Context) error {
+	return p.lp.Shutdown(ctx)
+}
+
+// NewLogrusHook creates a logrus hook which emits every entry as otel log record. The trace and span
+// are taken from the context of the entry.
+func (p *LogProvider) NewLogrusHook() logrus.Hook {
+	return &logProviderHook{logger: p.logger}
+}
+
+type logProviderHook struct {
+	logger log.Logger
+}
+
+func (h *logProviderHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *logProviderHook) Fire(entry *logrus.Entry) error {
+	ctx := entry.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	record := log.Record{}
+	record.SetTimestamp(entry.Time)
+	record.SetObservedTimestamp(time.Now())
+	record.SetSeverity(logSeverity(entry.Level))
+	record.SetSeverityText(entry.Level.String())
+	record.SetBody(log.StringValue(entry.Message))
+
+	attributes := make([]log.KeyValue, 0, len(entry.Data))
+	for k, v := range entry.Data {
+		if err, ok := v.(error); ok && k == logrus.ErrorKey {
+			record.SetErr(err)
+		}
+		attributes = append(attributes, log.KeyValue{Key: k, Value: logValue(v)})
+	}
+	record.AddAttributes(attributes...)
+
+	h.logger.Emit(ctx, record)
+	return nil
+}
+
+func logSeverity(level logrus.Level) log.Severity {
+	switch level {
+	case logrus.TraceLevel:
+		return log.SeverityTrace
+	case logrus.DebugLevel:
+		return log.SeverityDebug
+	case logrus.InfoLevel:
+		return log.SeverityInfo
+	case logrus.WarnLevel:
+		return log.SeverityWarn
+	case logrus.ErrorLevel:
+		return log.SeverityError
+	case logrus.FatalLevel:
+		return log.SeverityFatal
+	default:
+		return log.SeverityFatal4
+	}
+}
+
+func logValue(value any) log.Value {
+	switch v := value.(type) {
+	case nil:
+		return log.Value{}
+	case string:
+		return log.StringValue(v)
+	case bool:
+		return log.BoolValue(v)
+	case int:
+		return log.IntValue(v)
+	case int8:
+		return log.Int64Value(int64(v))
+	case int16:
+		return log.Int64Value(int64(v))
+	case int32:
+		return log.Int64Value(int64(v))
+	case int64:
+		return log.Int64Value(v)
+	case uint8:
+		return log.Int64Value(int64(v))
+	case uint16:
+		return log.Int64Value(int64(v))
+	case uint32:
+		return log.Int64Value(int64(v))
+	case uint:
+		return uintValue(uint64(v))
+	case uint64:
+		return uintValue(v)
+	case uintptr:
+		return uintValue(uint64(v))
+	case float32:
+		return log.Float64Value(float64(v))
+	case float64:
+		return log.Float64Value(v)
+	case []byte:
+		return log.BytesValue(v)
+	case error:
+		return log.StringValue(v.Error())
+	case time.Time:
+		return log.StringValue(v.Format(time.RFC3339Nano))
+	case fmt.Stringer:
+		return log.StringValue(v.String())
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		kvs := make([]log.KeyValue, 0, rv.Len())
+		for iter := rv.MapRange(); iter.Next(); {
+			kvs = append(kvs, log.KeyValue{Key: iter.Key().String(), Value: logValue(iter.Value().Interface())})
+		}
+		return log.MapValue(kvs...)
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		values := make([]log.Value, rv.Len())
+		for i := range values {
+			values[i] = logValue(rv.Index(i).Interface())
+		}
+		return log.SliceValue(values...)
+	}
+	return log.StringValue(fmt.Sprintf("%+v", value))
+}
+
+// uintValue returns values exceeding the int64 of otel as string, so they are not wrapped around.
+func uintValue(v uint64) log.Value {
+	if v > math.MaxInt64 {
+		return log.StringValue(strconv.FormatUint(v, 10))
+	}
+	return log.Int64Value(int64(v))
+}
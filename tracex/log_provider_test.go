@@ -0,0 +1,137 @@
+package tracex
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/log"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	collogs "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_LogProvider_ExportsRecordsWithTraceAndResource(t *testing.T) {
+	receiver := newOTLPReceiver(t)
+
+	traceProvider := NewTraceProvider(NewResource("sampleApp", "v1.0.0"), tracetest.NewNoopExporter())
+	logProvider, err := traceProvider.NewOTLPLogProvider(context.Background(), otlploghttp.WithEndpointURL(receiver.URL+"/v1/logs"))
+	require.NoError(t, err)
+	defer logProvider.Shutdown(context.Background())
+
+	logger := logrus.New()
+	logger.Out = io.Discard
+	logger.AddHook(logProvider.NewLogrusHook())
+
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+
+	logger.WithContext(ctx).
+		WithFields(logrus.Fields{"count": 3, "cookies": map[string]string{"session": "abc"}}).
+		WithError(errors.New("oops")).
+		Warn("something happened")
+	logger.Debug("not enabled")
+
+	require.NoError(t, logProvider.ForceFlush(context.Background()))
+
+	resourceLogs := receiver.resourceLogs()
+	require.Len(t, resourceLogs, 1)
+	resource := attributes(resourceLogs[0].Resource.Attributes)
+	assert.Equal(t, "sampleApp", resource["service.name"].GetStringValue())
+	assert.Equal(t, "v1.0.0", resource["service.version"].GetStringValue())
+
+	require.Len(t, resourceLogs[0].ScopeLogs, 1)
+	assert.Equal(t, "github.com/snabble/go-logging", resourceLogs[0].ScopeLogs[0].Scope.Name)
+
+	records := resourceLogs[0].ScopeLogs[0].LogRecords
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, "something happened", record.Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_WARN, record.SeverityNumber)
+	assert.Equal(t, "warning", record.SeverityText)
+	assert.Equal(t, spanContext.TraceID().String(), trace.TraceID(record.TraceId).String())
+	assert.Equal(t, spanContext.SpanID().String(), trace.SpanID(record.SpanId).String())
+
+	fields := attributes(record.Attributes)
+	assert.Equal(t, int64(3), fields["count"].GetIntValue())
+	assert.Equal(t, "oops", fields[logrus.ErrorKey].GetStringValue())
+	assert.Equal(t, "abc", fields["cookies"].GetKvlistValue().Values[0].Value.GetStringValue())
+}
+
+func Test_LogValue_Uints(t *testing.T) {
+	assert.Equal(t, log.Int64Value(42), logValue(uint(42)))
+	assert.Equal(t, log.Int64Value(42), logValue(uint64(42)))
+	assert.Equal(t, log.Int64Value(42), logValue(uintptr(42)))
+	assert.Equal(t, log.Int64Value(math.MaxInt64), logValue(uint64(math.MaxInt64)))
+	assert.Equal(t, log.StringValue("18446744073709551615"), logValue(uint64(math.MaxUint64)))
+}
+
+type otlpReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*collogs.ExportLogsServiceRequest
+}
+
+func newOTLPReceiver(t *testing.T) *otlpReceiver {
+	t.Helper()
+
+	receiver := &otlpReceiver{}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if !assert.NoError(t, err) || !assert.Equal(t, "/v1/logs", r.URL.Path) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		request := &collogs.ExportLogsServiceRequest{}
+		if !assert.NoError(t, proto.Unmarshal(body, request)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		receiver.mu.Lock()
+		receiver.requests = append(receiver.requests, request)
+		receiver.mu.Unlock()
+
+		response, _ := proto.Marshal(&collogs.ExportLogsServiceResponse{})
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(response)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *otlpReceiver) resourceLogs() []*logspb.ResourceLogs {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var result []*logspb.ResourceLogs
+	for _, request := range r.requests {
+		result = append(result, request.ResourceLogs...)
+	}
+	return result
+}
+
+func attributes(kvs []*commonpb.KeyValue) map[string]*commonpb.AnyValue {
+	result := map[string]*commonpb.AnyValue{}
+	for _, kv := range kvs {
+		result[kv.Key] = kv.Value
+	}
+	return result
+}
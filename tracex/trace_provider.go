@@ -15,7 +15,8 @@ import (
 )
 
 type TraceProvider struct {
-	tp *sdktrace.TracerProvider
+	tp       *sdktrace.TracerProvider
+	resource *resource.Resource
 }
 
 func NewGlobalNoopTraceProvider(serviceName, serviceSemanticVersion string) *TraceProvider {
@@ -30,10 +31,16 @@ func NewGlobalNoopTraceProvider(serviceName, serviceSemanticVersion string) *Tra
 
 func NewTraceProvider(appResource *resource.Resource, batcher sdktrace.SpanExporter) *TraceProvider {
 	return &TraceProvider{
-		tp: sdktrace.NewTracerProvider(sdktrace.WithResource(appResource), sdktrace.WithBatcher(batcher)),
+		tp:       sdktrace.NewTracerProvider(sdktrace.WithResource(appResource), sdktrace.WithBatcher(batcher)),
+		resource: appResource,
 	}
 }
 
+// Resource returns the attributes describing the application, e.g. service.name and service.version.
+func (p *TraceProvider) Resource() *resource.Resource {
+	return p.resource
+}
+
 func (p *TraceProvider) Shutdown(ctx context.Context) error {
 	err := p.tp.Shutdown(ctx)
 	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
@@ -45,6 +52,12 @@ func newTextMapPropagator() propagation.TextMapPropagator {
 	return propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
 }
 
+// NewResource creates the resource describing the application with its name, version and the
+// environment taken from ENV_NAME.
+func NewResource(serviceName, serviceSemVersion string) *resource.Resource {
+	return newResource(serviceName, serviceSemVersion, environment())
+}
+
 func newResource(serviceName, serviceSemVersion, environment string) *resource.Resource {
 	r, err := resource.Merge(
 		resource.Default(),